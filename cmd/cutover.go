@@ -0,0 +1,81 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/csfreak/dc2deploy/pkg/command"
+	"github.com/spf13/cobra"
+)
+
+var cutoverCmd = &cobra.Command{
+	Use:   "cutover name",
+	Short: "Migrate a live DeploymentConfig to a Deployment",
+	Long: `Migrate a live DeploymentConfig to a Deployment without downtime. The DeploymentConfig triggers are disabled, the Deployment is created and once it is available the Services and HorizontalPodAutoscalers of the DeploymentConfig are pointed at it, and the DeploymentConfig is scaled to zero and optionally deleted.
+
+Progress is recorded on the DeploymentConfig, so an interrupted cutover resumes when run again. If a step fails the DeploymentConfig replicas and triggers are restored and, once it is available again, the Services and HorizontalPodAutoscalers are pointed back at it and the Deployment and stash ConfigMap are removed.`,
+	Example: `
+dc2deploy cutover dcname -n namespacename --delete`,
+	Args:    validateCutoverArgs,
+	PreRunE: validateCutoverFlags,
+	RunE:    command.CutoverE,
+}
+
+func init() {
+	cutoverCmd.Flags().Bool("delete", false, "Delete the DeploymentConfig once it is scaled to zero")
+	cutoverCmd.Flags().Duration("grace-period", 0, "Time to wait after scaling the DeploymentConfig to zero before deleting it")
+	cutoverCmd.Flags().Duration("timeout", 10*time.Minute, "Time to wait for the Deployment to become available")
+
+	rootCmd.AddCommand(cutoverCmd)
+}
+
+func validateCutoverArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+		return err
+	}
+
+	return validateArgs(cmd, args)
+}
+
+func validateCutoverFlags(cmd *cobra.Command, args []string) error {
+	c := commandOptions(cmd, args)
+
+	if del, err := cmd.Flags().GetBool("delete"); err == nil {
+		c.CutoverDelete = del
+	}
+
+	if grace, err := cmd.Flags().GetDuration("grace-period"); err == nil {
+		c.CutoverGracePeriod = grace
+	}
+
+	if timeout, err := cmd.Flags().GetDuration("timeout"); err == nil {
+		c.WaitTimeout = timeout
+	}
+
+	if c.DumpDir != "" {
+		return fmt.Errorf("cannot cutover from a dump")
+	}
+
+	return command.SetCommandOptions(c)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/csfreak/dc2deploy/pkg/command"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [name]",
+	Short: "Compare a DeploymentConfig with its Deployment",
+	Long: `Compare the fields of a DeploymentConfig with the fields of the Deployment it converts to, aligned by field. Only fields that move, change, are defaulted or are dropped are listed, with label renames, dropped annotations and the strategy mapping noted. The pod template is compared field by field.
+
+With a Template file, each of its DeploymentConfigs is compared under its name, keeping the parameter references in the values.
+
+With a DeploymentConfig name, it is read from the cluster and the Deployment of the same name that already exists in the target namespace is shown as a third side, including the fields only it sets.`,
+	Example: `
+dc2deploy diff -f dc.yaml
+
+dc2deploy diff -f template.yaml
+
+dc2deploy diff dcname -n namespacename --target-context k8s -o json`,
+	Args:    validateArgs,
+	PreRunE: validateDiffFlags,
+	RunE:    command.DiffE,
+}
+
+func init() {
+	diffCmd.Flags().StringP("filename", "f", "-", "File containing DeploymentConfig manifest")
+	diffCmd.MarkFlagFilename("filename")
+	diffCmd.Flags().StringP("output", "o", "table", "Diff format, as 'table' or 'json'")
+	diffCmd.Flags().String("outfile", "-", "Output filename. Defaults to STDOUT")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func validateDiffFlags(cmd *cobra.Command, args []string) error {
+	c := commandOptions(cmd, args)
+	c.OutputFileType = ""
+
+	if output, err := cmd.Flags().GetString("output"); err == nil {
+		c.DiffFormat = command.ReportFormat(output)
+	}
+
+	return command.SetCommandOptions(c)
+}
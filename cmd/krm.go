@@ -0,0 +1,53 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/csfreak/dc2deploy/pkg/command"
+	"github.com/spf13/cobra"
+)
+
+var krmCmd = &cobra.Command{
+	Use:   "krm",
+	Short: "Run as a KRM function on a ResourceList",
+	Long: `Read a config.kubernetes.io/v1 ResourceList on STDIN, convert every DeploymentConfig in its items and write the list to STDOUT, for kustomize and kpt function pipelines. Services and HorizontalPodAutoscalers in the list that use a converted DeploymentConfig are rewritten to use its Deployment, and conversion warnings are reported as results.
+
+The functionConfig is a ConfigMap whose data sets target-namespace, registry-rewrite, stash, minimal, ignore-warnings, allow-warning and deny-warning like the flags of the same name. Lists are comma separated. A DeploymentConfig blocked by warnings is left as it is and the function fails.`,
+	Example: `
+kpt fn source manifests | dc2deploy krm | kpt fn sink converted
+
+kustomize build --enable-alpha-plugins --enable-exec overlay`,
+	Args:    cobra.NoArgs,
+	PreRunE: validateKRMFlags,
+	RunE:    command.KRME,
+}
+
+func init() {
+	rootCmd.AddCommand(krmCmd)
+}
+
+func validateKRMFlags(cmd *cobra.Command, args []string) error {
+	c := commandOptions(cmd, nil)
+	c.Filename = "-"
+
+	return command.SetCommandOptions(c)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/csfreak/dc2deploy/pkg/command"
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Undo a live migration from a backup",
+	Long: `Undo a live migration from the backup written before it changed the cluster. The DeploymentConfig is restored to its original replicas and triggers, or recreated if it was deleted, and once it is stable the objects the migration changed are restored as they were, and the objects it created are deleted.
+
+Rollback can be run again after a partial failure. When migrating between clusters, pass the same --target-context or --target-kubeconfig as the migration.`,
+	Example: `
+dc2deploy rollback -f namespacename-dcname-20220102T150405Z.dc2deploy-backup.yaml`,
+	Args:    cobra.NoArgs,
+	PreRunE: validateRollbackFlags,
+	RunE:    command.RollbackE,
+}
+
+func init() {
+	rollbackCmd.Flags().StringP("filename", "f", "", "Backup file written by a live migration")
+	rollbackCmd.MarkFlagFilename("filename")
+	rollbackCmd.Flags().Bool("scale-down", false, "Scale the created Deployment to zero instead of deleting it")
+	rollbackCmd.Flags().Duration("timeout", 10*time.Minute, "Time to wait for the restored DeploymentConfig to become stable")
+
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+func validateRollbackFlags(cmd *cobra.Command, args []string) error {
+	c := commandOptions(cmd, args)
+
+	if filename, err := cmd.Flags().GetString("filename"); err == nil {
+		c.BackupFilename = filename
+	}
+
+	if c.BackupFilename == "" {
+		return fmt.Errorf("a backup filename is required")
+	}
+
+	if scaledown, err := cmd.Flags().GetBool("scale-down"); err == nil {
+		c.RollbackScaleDown = scaledown
+	}
+
+	if timeout, err := cmd.Flags().GetDuration("timeout"); err == nil {
+		c.WaitTimeout = timeout
+	}
+
+	return command.SetCommandOptions(c)
+}
@@ -23,11 +23,23 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"time"
 
 	"github.com/csfreak/dc2deploy/pkg/command"
+	"github.com/csfreak/dc2deploy/pkg/convert"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	// configFlags are the kubectl connection flags, such as --kubeconfig,
+	// --context, --server, --token and --as.
+	configFlags     = genericclioptions.NewConfigFlags(true)
+	connectionFlags = pflag.NewFlagSet("connection", pflag.ExitOnError)
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -38,9 +50,40 @@ var rootCmd = &cobra.Command{
 	Example: `
 From File:
 dc2deploy -f dc.yaml --output deploy.yaml
-	
+
+Without defaults and empty fields, ready to commit:
+dc2deploy -f dc.yaml --minimal --outfile deploy.yaml
+
+From an OpenShift Template, keeping its parameters:
+dc2deploy -f template.yaml --outfile template-deploy.yaml
+
+Rewriting the DeploymentConfigs of a file in place:
+dc2deploy -f manifests.yaml --in-place
+
+As a Helm chart:
+dc2deploy -f template.yaml --helm-chart charts/myapp --helm-hooks
+
+As a kustomize base with overlays:
+dc2deploy -f dc-dev.yaml --kustomize deploy --overlay stage=dc-stage.yaml,prod=dc-prod.yaml
+
 From Kubernetes:
-dc2deploy dcname -n namespacename --dry-run`,
+dc2deploy dcname -n namespacename --dry-run
+
+With kubectl printers, such as a table of the objects written:
+dc2deploy -f template.yaml -o wide
+dc2deploy dcname -n namespacename -o jsonpath='{.spec.template.spec.containers[*].image}'
+
+Between clusters:
+dc2deploy dcname -n namespacename --source-context ocp --target-context k8s --registry-rewrite docker-registry.default.svc:5000=registry.example.com
+
+With ConfigMaps, Secrets and other dependencies:
+dc2deploy dcname -n namespacename --with-dependencies --include-secrets --dry-run
+
+From an 'oc adm inspect' or must-gather directory:
+dc2deploy dcname -n namespacename --from-dump inspect.local.123456
+
+Validate against the cluster without persisting:
+dc2deploy dcname -n namespacename --dry-run=server`,
 	Args:    validateArgs,
 	PreRunE: validateFlags,
 	RunE:    command.RunE,
@@ -50,28 +93,62 @@ dc2deploy dcname -n namespacename --dry-run`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(command.ExitCode(err))
 	}
 }
 
 func init() {
-	rootCmd.Flags().StringP("filename", "f", "-", "File containing DeploymentConfig manifest")
+	rootCmd.Flags().StringP("filename", "f", "-", "File containing DeploymentConfig or Template manifest")
 	rootCmd.MarkFlagFilename("filename")
 
 	// Live Flags
-	rootCmd.Flags().Bool("dry-run", false, "Only print the new object that would be sent")
-	rootCmd.Flags().StringP("namespace", "n", "", "Namespace of DeploymentConfig")
-	rootCmd.Flags().String("kubeconfig", "", "Path to Kubeconfig")
+	rootCmd.Flags().String("dry-run", string(command.NoDryRun), "Only print the new object that would be sent. 'client' (or 'true') prints the local conversion, 'server' sends it with dryRun=All and 'none' (or 'false') creates it")
+	rootCmd.Flags().Lookup("dry-run").NoOptDefVal = string(command.ClientDryRun)
+	rootCmd.PersistentFlags().Bool("force-conflicts", false, "Take ownership of fields managed by other field managers when applying")
+	configFlags.AddFlags(connectionFlags)
+	rootCmd.PersistentFlags().AddFlagSet(connectionFlags)
+	rootCmd.PersistentFlags().String("source-context", "", "The name of the kubeconfig context to read the DeploymentConfig from. Same as --context")
+	rootCmd.PersistentFlags().String("source-kubeconfig", "", "Path to the kubeconfig file to read the DeploymentConfig with. Same as --kubeconfig")
+	rootCmd.PersistentFlags().String("target-context", "", "The name of the kubeconfig context to write the Deployment to. Defaults to the source cluster")
+	rootCmd.PersistentFlags().String("target-kubeconfig", "", "Path to the kubeconfig file to write the Deployment with. Defaults to the source kubeconfig")
+	rootCmd.PersistentFlags().String("target-namespace", "", "Namespace to write the Deployment to. Defaults to the namespace of the DeploymentConfig")
+	rootCmd.PersistentFlags().String("from-dump", "", "Read DeploymentConfigs and related objects from an 'oc adm inspect' or must-gather directory instead of a cluster")
+	rootCmd.MarkPersistentFlagDirname("from-dump")
+	rootCmd.PersistentFlags().StringToString("registry-rewrite", nil, "Rewrite image registries, as old=new, for example docker-registry.default.svc:5000=registry.example.com")
+	rootCmd.MarkFlagsMutuallyExclusive("source-context", "context")
+	rootCmd.MarkFlagsMutuallyExclusive("source-kubeconfig", "kubeconfig")
+	rootCmd.PersistentFlags().Duration("wait-stable", 0, "Wait up to this long for an in progress DeploymentConfig rollout to finish")
+	rootCmd.Flags().Bool("wait", false, "Wait for the created Deployment to finish rolling out")
+	rootCmd.Flags().Duration("timeout", 10*time.Minute, "Time to wait for the Deployment rollout with --wait")
+	rootCmd.Flags().Bool("with-dependencies", false, "Also export the ConfigMaps, PersistentVolumeClaims, ServiceAccount, Roles, RoleBindings and ImageStreams the DeploymentConfig uses, and the Services and HorizontalPodAutoscalers that target it")
+	rootCmd.Flags().Bool("include-secrets", false, "Also export the Secrets the DeploymentConfig uses with --with-dependencies. The output will contain secret data")
+	rootCmd.PersistentFlags().String("backup-dir", ".", "Directory to write a backup to before changing the cluster")
 	rootCmd.MarkFlagsMutuallyExclusive("kubeconfig", "filename")
 
 	// Output Flags
 	rootCmd.Flags().String("outfile", "-", "Output filename. Defaults to STDOUT")
-	rootCmd.Flags().StringP("output", "o", "yaml", "Output in JSON")
+	rootCmd.Flags().StringP("output", "o", "yaml", "Output format. One of: yaml, json, name, table, wide, jsonpath=..., jsonpath-file=..., go-template=..., go-template-file=... or custom-columns=.... Applied objects are printed in a live conversion unless yaml or json")
+	rootCmd.Flags().String("helm-chart", "", "Write a Helm chart to this directory instead of the converted objects. Template parameters become values, and the replicas, images and resources of each Deployment become values under deployments.<name>. The chart holds one DeploymentConfig, or the DeploymentConfigs of one Template; put several DeploymentConfigs in a Template to chart them together")
+	rootCmd.MarkFlagDirname("helm-chart")
+	rootCmd.Flags().Bool("helm-hooks", false, "Also convert the execNewPod lifecycle hooks to Helm hook Jobs with --helm-chart")
+	rootCmd.Flags().Bool("minimal", false, "Leave out empty fields, fields set by the cluster and fields equal to the Kubernetes defaults")
+	rootCmd.Flags().Bool("in-place", false, "Replace the DeploymentConfigs in the input file with their conversion, keeping comments, key order and other documents. Each converted document is indented again, with the sequence indentation it uses first")
+	rootCmd.Flags().String("kustomize", "", "Write a kustomize base and overlays to this directory instead of the converted objects")
+	rootCmd.MarkFlagDirname("kustomize")
+	rootCmd.Flags().StringToString("overlay", nil, "Kustomize overlays, as name=file, each holding a variant of the DeploymentConfig, for example prod=dc-prod.yaml")
+	rootCmd.MarkFlagsMutuallyExclusive("helm-chart", "kustomize")
 
 	// Options
-	rootCmd.Flags().Bool("ignore-warnings", false, "Ignore Warnings about missing Deployment Features")
+	rootCmd.PersistentFlags().Bool("ignore-warnings", false, "Ignore Warnings about missing Deployment Features")
+	rootCmd.PersistentFlags().String("checks", "", "File containing custom CEL checks")
+	rootCmd.MarkPersistentFlagFilename("checks")
+	rootCmd.PersistentFlags().StringSlice("allow-warning", nil, "Warning codes that never block conversion")
+	rootCmd.PersistentFlags().StringSlice("deny-warning", nil, "Warning codes that always block conversion, even with --ignore-warnings")
+	rootCmd.Flags().String("warnings-format", "", "Only write conversion warnings, as 'json', 'yaml', 'sarif' or 'junit'")
+	rootCmd.PersistentFlags().String("provenance", "", "Print a field provenance report to STDERR as 'table' or 'json'")
+	rootCmd.PersistentFlags().String("stash", "", "Record unmapped DeploymentConfig fields in an 'annotation' or 'configmap'")
 
-	rootCmd.Flags().UintP("verbosity", "v", 0, "Set Verbosity")
+	rootCmd.PersistentFlags().UintP("verbosity", "v", 0, "Set Verbosity")
 }
 
 func validateArgs(cmd *cobra.Command, args []string) error {
@@ -89,6 +166,11 @@ func validateArgs(cmd *cobra.Command, args []string) error {
 }
 
 func validateFlags(cmd *cobra.Command, args []string) error {
+	return command.SetCommandOptions(commandOptions(cmd, args))
+}
+
+// commandOptions reads the flags shared by all commands.
+func commandOptions(cmd *cobra.Command, args []string) *command.CommandOptions {
 	c := &command.CommandOptions{}
 
 	if filename, err := cmd.Flags().GetString("filename"); err == nil {
@@ -107,25 +189,170 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 		c.LiveNamespace = namespace
 	}
 
-	if kubeconfig, err := cmd.Flags().GetString("kubeconfig"); err == nil {
-		c.LiveKubeconfig = kubeconfig
+	if context, err := cmd.Flags().GetString("source-context"); err == nil && context != "" {
+		configFlags.Context = &context
+	}
+
+	if kubeconfig, err := cmd.Flags().GetString("source-kubeconfig"); err == nil && kubeconfig != "" {
+		configFlags.KubeConfig = &kubeconfig
+	}
+
+	c.LiveConfig = configFlags
+
+	connectionFlags.VisitAll(func(f *pflag.Flag) {
+		if f.Name != "namespace" && cmd.Flags().Changed(f.Name) {
+			c.LiveConfigSet = true
+		}
+	})
+
+	if cmd.Flags().Changed("source-context") || cmd.Flags().Changed("source-kubeconfig") {
+		c.LiveConfigSet = true
+	}
+
+	c.TargetConfig = targetConfig(cmd)
+
+	if dir, err := cmd.Flags().GetString("from-dump"); err == nil {
+		c.DumpDir = dir
+	}
+
+	if namespace, err := cmd.Flags().GetString("target-namespace"); err == nil {
+		c.TargetNamespace = namespace
+	}
+
+	if rewrites, err := cmd.Flags().GetStringToString("registry-rewrite"); err == nil {
+		c.RegistryRewrites = rewrites
 	}
 
 	if len(args) == 1 {
 		c.LiveDC = args[0]
 	}
 
-	if dryrun, err := cmd.Flags().GetBool("dry-run"); err == nil {
-		c.LiveDryRun = dryrun
+	if wait, err := cmd.Flags().GetBool("wait"); err == nil {
+		c.LiveWait = wait
+	}
+
+	if timeout, err := cmd.Flags().GetDuration("timeout"); err == nil {
+		c.WaitTimeout = timeout
+	}
+
+	if deps, err := cmd.Flags().GetBool("with-dependencies"); err == nil {
+		c.Dependencies = deps
+	}
+
+	if secrets, err := cmd.Flags().GetBool("include-secrets"); err == nil {
+		c.IncludeSecrets = secrets
+	}
+
+	if dir, err := cmd.Flags().GetString("backup-dir"); err == nil {
+		c.BackupDir = dir
+	}
+
+	if wait, err := cmd.Flags().GetDuration("wait-stable"); err == nil {
+		c.LiveWaitStable = wait
+	}
+
+	c.LiveDryRun = command.NoDryRun
+
+	if dryrun, err := cmd.Flags().GetString("dry-run"); err == nil {
+		// true and false are accepted as kubectl once did.
+		switch dryrun {
+		case "true":
+			c.LiveDryRun = command.ClientDryRun
+		case "false":
+			c.LiveDryRun = command.NoDryRun
+		default:
+			c.LiveDryRun = command.DryRunMode(dryrun)
+		}
+	}
+
+	if force, err := cmd.Flags().GetBool("force-conflicts"); err == nil {
+		c.ForceConflicts = force
 	}
 
 	if ignore, err := cmd.Flags().GetBool("ignore-warnings"); err == nil {
 		c.IgnoreWarnings = ignore
 	}
 
-	if verbosity, err := cmd.Flags().GetUint8("verbosity"); err == nil {
-		c.Verbosity = verbosity
+	if stash, err := cmd.Flags().GetString("stash"); err == nil {
+		c.Stash = convert.StashMode(stash)
+	}
+
+	if provenance, err := cmd.Flags().GetString("provenance"); err == nil {
+		c.Provenance = command.ReportFormat(provenance)
+	}
+
+	if checks, err := cmd.Flags().GetString("checks"); err == nil {
+		c.ChecksFilename = checks
+	}
+
+	if allow, err := cmd.Flags().GetStringSlice("allow-warning"); err == nil {
+		c.AllowWarnings = allow
+	}
+
+	if deny, err := cmd.Flags().GetStringSlice("deny-warning"); err == nil {
+		c.DenyWarnings = deny
+	}
+
+	if format, err := cmd.Flags().GetString("warnings-format"); err == nil {
+		c.WarningsFormat = command.ReportFormat(format)
+	}
+
+	if chart, err := cmd.Flags().GetString("helm-chart"); err == nil {
+		c.HelmChart = chart
+	}
+
+	if hooks, err := cmd.Flags().GetBool("helm-hooks"); err == nil {
+		c.HelmHooks = hooks
+	}
+
+	if minimal, err := cmd.Flags().GetBool("minimal"); err == nil {
+		c.Minimal = minimal
+	}
+
+	if inPlace, err := cmd.Flags().GetBool("in-place"); err == nil {
+		c.InPlace = inPlace
+	}
+
+	if dir, err := cmd.Flags().GetString("kustomize"); err == nil {
+		c.KustomizeDir = dir
+	}
+
+	if overlays, err := cmd.Flags().GetStringToString("overlay"); err == nil {
+		c.Overlays = overlays
+	}
+
+	if verbosity, err := cmd.Flags().GetUint("verbosity"); err == nil {
+		if verbosity > math.MaxUint8 {
+			verbosity = math.MaxUint8
+		}
+
+		c.Verbosity = uint8(verbosity)
+	}
+
+	return c
+}
+
+// targetConfig returns the connection flags of the target cluster, or nil to
+// write to the source cluster. The target uses the source kubeconfig unless
+// --target-kubeconfig is given.
+func targetConfig(cmd *cobra.Command) genericclioptions.RESTClientGetter {
+	context, _ := cmd.Flags().GetString("target-context")
+	kubeconfig, _ := cmd.Flags().GetString("target-kubeconfig")
+
+	if context == "" && kubeconfig == "" {
+		return nil
+	}
+
+	target := genericclioptions.NewConfigFlags(true)
+	target.KubeConfig = configFlags.KubeConfig
+
+	if kubeconfig != "" {
+		target.KubeConfig = &kubeconfig
+	}
+
+	if context != "" {
+		target.Context = &context
 	}
 
-	return command.SetCommandOptions(c)
+	return target
 }
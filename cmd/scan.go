@@ -0,0 +1,79 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/command"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/validation"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [namespace...]",
+	Short: "Report the migration readiness of DeploymentConfigs",
+	Long: `Report the migration readiness of every DeploymentConfig in the given namespaces, the --namespace or the current namespace. Each DeploymentConfig is run through the same checks as a live conversion and counted as ready, review or blocked, with a rollup per namespace.
+
+Blocking warnings block and warn warnings need review, whether or not --ignore-warnings is set. --allow-warning, --deny-warning and --checks change the result as they would when converting.`,
+	Example: `
+dc2deploy scan namespace1 namespace2
+
+dc2deploy scan --all-namespaces -o html --outfile readiness.html`,
+	Args:    validateScanArgs,
+	PreRunE: validateScanFlags,
+	RunE:    command.ScanE,
+}
+
+func init() {
+	scanCmd.Flags().BoolP("all-namespaces", "A", false, "Scan DeploymentConfigs in all namespaces")
+	scanCmd.Flags().StringP("output", "o", "table", "Report format, as 'table', 'csv', 'json' or 'html'")
+	scanCmd.Flags().String("outfile", "-", "Output filename. Defaults to STDOUT")
+
+	rootCmd.AddCommand(scanCmd)
+}
+
+func validateScanArgs(cmd *cobra.Command, args []string) error {
+	for _, ns := range args {
+		if errs := validation.ValidateNamespaceName(ns, false); errs != nil {
+			return fmt.Errorf("invalid namespace name: %s", errs)
+		}
+	}
+
+	return nil
+}
+
+func validateScanFlags(cmd *cobra.Command, args []string) error {
+	c := commandOptions(cmd, nil)
+	c.OutputFileType = ""
+	c.ScanNamespaces = args
+
+	if all, err := cmd.Flags().GetBool("all-namespaces"); err == nil {
+		c.ScanAllNamespaces = all
+	}
+
+	if output, err := cmd.Flags().GetString("output"); err == nil {
+		c.ScanFormat = command.ReportFormat(output)
+	}
+
+	return command.SetCommandOptions(c)
+}
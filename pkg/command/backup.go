@@ -0,0 +1,317 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	BackupAPIVersion = "dc2deploy/v1"
+	BackupKind       = "Backup"
+	BackupSuffix     = ".dc2deploy-backup.yaml"
+	BackupTimeFormat = "20060102T150405Z"
+)
+
+// Backup is written before a live operation changes the cluster. It holds the
+// DeploymentConfig as it was and the objects the operation creates or changes,
+// so DoRollback can undo the operation.
+type Backup struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Timestamp        metav1.Time                        `json:"timestamp"`
+	DeploymentConfig *ocappsv1.DeploymentConfig         `json:"deploymentConfig"`
+	Replicas         int32                              `json:"replicas"`
+	Triggers         ocappsv1.DeploymentTriggerPolicies `json:"triggers"`
+	Objects          []BackupObject                     `json:"objects,omitempty"`
+}
+
+// BackupObject refers to an object a live operation creates or changes. If it
+// Existed, Manifest holds it as it was, without the fields the server sets.
+type BackupObject struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Namespace  string                 `json:"namespace,omitempty"`
+	Name       string                 `json:"name"`
+	Existed    bool                   `json:"existed,omitempty"`
+	Manifest   map[string]interface{} `json:"manifest,omitempty"`
+}
+
+// writeBackup writes a Backup of dc, the objects in obj and the changed
+// objects to a new file in BackupDir before the operation changes them.
+func writeBackup(dc *ocappsv1.DeploymentConfig, obj runtime.Object, changed ...*unstructured.Unstructured) error {
+	objs, err := k8s.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	b := &Backup{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: BackupAPIVersion,
+			Kind:       BackupKind,
+		},
+		Timestamp:        metav1.Now(),
+		DeploymentConfig: dc,
+		Replicas:         dc.Spec.Replicas,
+		Triggers:         dc.Spec.Triggers,
+	}
+
+	for _, o := range append(objs, changed...) {
+		bo, err := backupObject(o)
+		if err != nil {
+			return err
+		}
+
+		b.Objects = append(b.Objects, bo)
+	}
+
+	data, err := sigsyaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("unable to marshal backup: %w", err)
+	}
+
+	// a backup is never overwritten, as it may be the only record of the
+	// state before an earlier run.
+	path := filepath.Join(Options.BackupDir, fmt.Sprintf("%s-%s-%s%s",
+		dc.Namespace, dc.Name, b.Timestamp.UTC().Format(BackupTimeFormat), BackupSuffix))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to write backup: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write backup: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write backup: %w", err)
+	}
+
+	writer.WriteErr(0, "wrote backup %s", path)
+
+	return nil
+}
+
+// backupObject refers to o and, if it exists, holds it as it is now.
+func backupObject(o *unstructured.Unstructured) (BackupObject, error) {
+	bo := BackupObject{
+		APIVersion: o.GetAPIVersion(),
+		Kind:       o.GetKind(),
+		Namespace:  o.GetNamespace(),
+		Name:       o.GetName(),
+	}
+
+	current, err := target.Get(o)
+
+	switch {
+	case apierrors.IsNotFound(err):
+		return bo, nil
+	case err != nil:
+		return bo, err
+	}
+
+	for _, f := range [][]string{
+		{"metadata", "resourceVersion"},
+		{"metadata", "uid"},
+		{"metadata", "generation"},
+		{"metadata", "creationTimestamp"},
+		{"metadata", "managedFields"},
+		{"metadata", "selfLink"},
+		{"status"},
+	} {
+		unstructured.RemoveNestedField(current.Object, f...)
+	}
+
+	bo.Existed = true
+	bo.Manifest = current.Object
+
+	return bo, nil
+}
+
+func loadBackup(path string) (*Backup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file: %w", err)
+	}
+
+	b := &Backup{}
+
+	if err := yaml.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("unable to parse backup: %w", err)
+	}
+
+	if b.Kind != BackupKind || b.DeploymentConfig == nil {
+		return nil, fmt.Errorf("%s is not a %s %s", path, BackupAPIVersion, BackupKind)
+	}
+
+	return b, nil
+}
+
+// DoRollback restores the DeploymentConfig in a Backup, recreating it if it
+// was deleted, and once it is stable restores the objects the backed up
+// operation changed and deletes or scales down the objects it created. Objects
+// already removed are skipped, so it can be run again after a partial failure.
+func DoRollback() error {
+	if err := initClient(); err != nil {
+		return err
+	}
+
+	b, err := loadBackup(Options.BackupFilename)
+	if err != nil {
+		return fmt.Errorf("unable to load %s: %w", Options.BackupFilename, err)
+	}
+
+	return rollbackBackup(b)
+}
+
+// rollbackBackup undoes the operation backed up in b.
+func rollbackBackup(b *Backup) error {
+	if err := restoreDC(b); err != nil {
+		return err
+	}
+
+	// the DeploymentConfig pods must be back before Services are pointed at
+	// them again and the Deployment is removed.
+	dc := b.DeploymentConfig
+	if _, err := source.WaitForStableDC(dc.Name, dc.Namespace, Options.WaitTimeout); err != nil {
+		return err
+	}
+
+	for i := len(b.Objects) - 1; i >= 0; i-- {
+		o := b.Objects[i]
+
+		var err error
+
+		if o.Existed {
+			err = restoreObject(o)
+		} else {
+			err = removeCreated(o)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.WriteErr(0, "rollback of %s/%s complete", dc.Namespace, dc.Name)
+
+	return nil
+}
+
+func restoreDC(b *Backup) error {
+	dc := b.DeploymentConfig
+
+	_, err := source.LoadDC(dc.Name, dc.Namespace)
+	if apierrors.IsNotFound(err) {
+		restored := dc.DeepCopy()
+		restored.TypeMeta = metav1.TypeMeta{
+			APIVersion: ocappsv1.GroupVersion.String(),
+			Kind:       "DeploymentConfig",
+		}
+		restored.ObjectMeta = metav1.ObjectMeta{
+			Name:        dc.Name,
+			Namespace:   dc.Namespace,
+			Labels:      dc.Labels,
+			Annotations: dc.Annotations,
+		}
+		restored.Status = ocappsv1.DeploymentConfigStatus{}
+		restored.Spec.Replicas = b.Replicas
+		restored.Spec.Triggers = b.Triggers
+
+		writer.WriteErr(0, "recreating deploymentconfig %s", dc.Name)
+
+		return source.Create(restored)
+	} else if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				convert.CutoverStateAnnotationKey: nil,
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas": b.Replicas,
+			"triggers": b.Triggers,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to marshal patch: %w", err)
+	}
+
+	writer.WriteErr(0, "restoring deploymentconfig %s to %d replicas", dc.Name, b.Replicas)
+
+	_, err = source.PatchDC(dc.Name, dc.Namespace, patch)
+
+	return err
+}
+
+func restoreObject(o BackupObject) error {
+	writer.WriteErr(0, "restoring %s %s", o.Kind, o.Name)
+
+	return target.Replace(&unstructured.Unstructured{Object: o.Manifest})
+}
+
+func removeCreated(o BackupObject) error {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(o.APIVersion)
+	u.SetKind(o.Kind)
+	u.SetNamespace(o.Namespace)
+	u.SetName(o.Name)
+
+	var err error
+
+	if o.Kind == "Deployment" && Options.RollbackScaleDown {
+		writer.WriteErr(0, "scaling down %s %s", o.Kind, o.Name)
+
+		err = target.Patch(u, []byte(`{"spec":{"replicas":0}}`))
+	} else {
+		writer.WriteErr(0, "deleting %s %s", o.Kind, o.Name)
+
+		err = target.Delete(u)
+	}
+
+	if apierrors.IsNotFound(err) {
+		writer.WriteErr(1, "%s %s already removed", o.Kind, o.Name)
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,221 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+// newTestBackup backs up a migration of the DeploymentConfig app in a fake
+// cluster, then migrates it by scaling the DeploymentConfig down, pointing the
+// Service at the Deployment and creating the Deployment. The DeploymentConfig
+// reports a rollout in progress unless stable is set.
+func newTestBackup(t *testing.T, stable bool) (*Backup, *fake.FakeDynamicClient) {
+	t.Helper()
+
+	dc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.openshift.io/v1",
+		"kind":       "DeploymentConfig",
+		"metadata":   map[string]interface{}{"name": "app", "namespace": "ns"},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"triggers": []interface{}{map[string]interface{}{"type": "ConfigChange"}},
+		},
+		"status": map[string]interface{}{
+			"updatedReplicas":   int64(2),
+			"availableReplicas": int64(2),
+		},
+	}}
+
+	if !stable {
+		dc.Object["status"].(map[string]interface{})["availableReplicas"] = int64(0)
+	}
+
+	service := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "app", "namespace": "ns"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"deploymentconfig": "app"},
+		},
+	}}
+
+	deploy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "app", "namespace": "ns"},
+	}}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), dc, service.DeepCopy())
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Service"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+	} {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+
+	oldOptions, oldSource, oldTarget := Options, source, target
+
+	t.Cleanup(func() {
+		Options, source, target = oldOptions, oldSource, oldTarget
+	})
+
+	Options = &CommandOptions{WaitTimeout: 10 * time.Millisecond, BackupDir: t.TempDir()}
+	source = &k8s.Cluster{Client: client, Mapper: mapper, Namespace: "ns"}
+	target = source
+
+	loaded, err := source.LoadDC("app", "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := convert.ToList(deploy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeBackup(loaded, obj, service); err != nil {
+		t.Fatalf("writeBackup() error = %v", err)
+	}
+
+	if _, err := source.PatchDC("app", "ns", []byte(`{"spec":{"replicas":0,"triggers":[]}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated := service.DeepCopy()
+	migrated.Object["spec"] = map[string]interface{}{"selector": map[string]interface{}{"deployment": "app"}}
+
+	if err := target.Replace(migrated); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := target.Create(deploy); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(Options.BackupDir, "*"+BackupSuffix))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("backup files = %v, error = %v", files, err)
+	}
+
+	b, err := loadBackup(files[0])
+	if err != nil {
+		t.Fatalf("loadBackup() error = %v", err)
+	}
+
+	client.ClearActions()
+
+	return b, client
+}
+
+func TestRollbackBackup(t *testing.T) {
+	tests := []struct {
+		name    string
+		stable  bool
+		actions []string
+		err     bool
+	}{
+		{
+			name:   "stable deploymentconfig",
+			stable: true,
+			actions: []string{
+				"get deploymentconfigs",
+				"patch deploymentconfigs",
+				"get deploymentconfigs",
+				"get services",
+				"update services",
+				"delete deployments",
+			},
+		},
+		{
+			name:   "rollout in progress",
+			stable: false,
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, client := newTestBackup(t, tt.stable)
+
+			err := rollbackBackup(b)
+			if (err != nil) != tt.err {
+				t.Fatalf("rollbackBackup() error = %v, want error %v", err, tt.err)
+			}
+
+			for _, a := range actions(client) {
+				if tt.err && !strings.HasSuffix(a, " deploymentconfigs") {
+					t.Errorf("rollbackBackup() changed %s before the deploymentconfig was stable", a)
+				}
+			}
+
+			if tt.err {
+				return
+			}
+
+			if got := actions(client); !reflect.DeepEqual(got, tt.actions) {
+				t.Errorf("rollbackBackup() made requests\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.actions, "\n"))
+			}
+
+			dc, err := source.LoadDC("app", "ns")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if dc.Spec.Replicas != 2 || len(dc.Spec.Triggers) != 1 {
+				t.Errorf("rollbackBackup() left dc replicas %d, triggers %v", dc.Spec.Replicas, dc.Spec.Triggers)
+			}
+
+			if _, err := target.GetDeployment("app", "ns"); err == nil {
+				t.Errorf("rollbackBackup() left the created deployment")
+			}
+
+			svc, err := target.Get(&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]interface{}{"name": "app", "namespace": "ns"},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector"); !reflect.DeepEqual(selector, map[string]string{"deploymentconfig": "app"}) {
+				t.Errorf("rollbackBackup() left service selector %v", selector)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"errors"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// bundledDependencies holds the dependencies of the DeploymentConfigs looked up
+// so far, so the preflight checks and bundle share one lookup.
+var bundledDependencies = map[*ocappsv1.DeploymentConfig][]*unstructured.Unstructured{}
+
+// dependencies returns the dependencies of dc on the source cluster, moved to
+// the target namespace.
+func dependencies(dc *ocappsv1.DeploymentConfig) ([]*unstructured.Unstructured, error) {
+	if deps, ok := bundledDependencies[dc]; ok {
+		return deps, nil
+	}
+
+	deps, err := source.Dependencies(dc, targetNamespace(dc), Options.IncludeSecrets)
+	if err != nil {
+		return nil, err
+	}
+
+	bundledDependencies[dc] = deps
+
+	return deps, nil
+}
+
+// bundle returns a List of the dependencies of dc followed by obj, the
+// conversion of dc, so the dependencies are created first. Unless only the
+// local conversion is printed, dependencies that already exist on the target
+// cluster or that it does not serve are left out, so they are not changed and
+// rollback does not delete them.
+func bundle(dc *ocappsv1.DeploymentConfig, obj runtime.Object) (runtime.Object, error) {
+	deps, err := dependencies(dc)
+	if err != nil {
+		return nil, err
+	}
+
+	if Options.LiveDryRun != ClientDryRun {
+		deps, err = missingDependencies(deps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	objs, err := k8s.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert.ToList(toObjects(append(deps, objs...))...)
+}
+
+func missingDependencies(deps []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	var result []*unstructured.Unstructured
+
+	for _, u := range deps {
+		exists, err := target.Exists(u)
+
+		switch {
+		case errors.Is(err, k8s.ErrNotServed):
+			writer.WriteErr(0, "skipped %s %s: %v", u.GetKind(), u.GetName(), err)
+		case err != nil:
+			return nil, err
+		case exists:
+			writer.WriteErr(1, "skipped %s %s: already exists", u.GetKind(), u.GetName())
+		default:
+			result = append(result, u)
+		}
+	}
+
+	return result, nil
+}
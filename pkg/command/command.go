@@ -27,7 +27,11 @@ import (
 
 	"github.com/csfreak/dc2deploy/pkg/convert"
 	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	templatev1 "github.com/openshift/api/template/v1"
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func RunE(cmd *cobra.Command, args []string) error {
@@ -40,46 +44,143 @@ func RunE(cmd *cobra.Command, args []string) error {
 	return DoConvert()
 }
 
+func CutoverE(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	return DoCutover()
+}
+
+func RollbackE(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	return DoRollback()
+}
+
+func ScanE(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	return DoScan()
+}
+
+func DiffE(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	return DoDiff()
+}
+
+func KRME(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	return DoKRM(cmd.InOrStdin())
+}
+
 func DoConvert() error {
-	dc, err := convert.LoadDC(Options.Filename)
+	if Options.InPlace {
+		return convertInPlace()
+	}
+
+	in, err := convert.Load(Options.Filename)
 	if err != nil {
 		return fmt.Errorf("unable to load %s: %w", Options.Filename, err)
 	}
 
-	warnings := convert.CheckFeatures(dc)
-	if warnings != nil {
-		checkWarnings(warnings)
+	var dc *ocappsv1.DeploymentConfig
 
-		if !Options.IgnoreWarnings {
-			return fmt.Errorf("use --ignore-warnings to continue")
+	switch t := in.(type) {
+	case *templatev1.Template:
+		if Options.KustomizeDir != "" {
+			return fmt.Errorf("cannot write a kustomize layout from a template")
 		}
+
+		return convertTemplate(t)
+	case *ocappsv1.DeploymentConfig:
+		dc = t
+	}
+
+	warnings, blocking, err := evaluateWarnings(dc)
+	if err != nil {
+		return err
 	}
 
-	deploy, err := convert.ToDeploy(dc)
+	if Options.WarningsFormat != "" {
+		return writeWarnings(warningsResult(dc, warnings))
+	}
+
+	if err := checkWarnings(warnings, blocking); err != nil {
+		return err
+	}
+
+	obj, err := convertDC(dc)
 	if err != nil {
-		return fmt.Errorf("unable to convert to deploy: %w", err)
+		return err
 	}
 
-	o, err := convert.ToOuput(deploy, string(Options.OutputFileType))
+	if Options.HelmChart != "" {
+		objs, err := chartObjects(dc, obj)
+		if err != nil {
+			return err
+		}
+
+		return writeChart(nil, objs)
+	}
+
+	if Options.KustomizeDir != "" {
+		return writeKustomize(obj)
+	}
+
+	p := newPrinted(ConvertedAction)
+	p.warnings[dc.Name] = len(warnings)
+
+	o, err := output(obj, p)
 	if err != nil {
-		return fmt.Errorf("unable to marshal object: %w", err)
+		return err
 	}
 
 	return writer.WriteFile(Options.OutputFilename, o)
 }
 
-func checkWarnings(w []*convert.Warning) {
-	var warningLogLevel uint8
+// convertDC converts dc and attaches the stash of unmapped fields, returning a
+// List when the stash is written to a ConfigMap. With Minimal, the result is
+// stripped of defaults and empty fields.
+func convertDC(dc *ocappsv1.DeploymentConfig) (runtime.Object, error) {
+	deploy, p, err := toDeploy(dc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := printProvenance(p); err != nil {
+		return nil, err
+	}
 
-	if Options.IgnoreWarnings {
-		warningLogLevel = 2
+	cm, err := convert.NewStash(dc).Apply(deploy, Options.Stash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stash unmapped fields: %w", err)
+	}
 
-		writer.WriteErr(2, "ignoring warnings")
-	} else {
-		warningLogLevel = 0
+	var obj runtime.Object = deploy
+
+	if cm != nil {
+		obj, err = convert.ToList(deploy, cm)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	for _, warning := range w {
-		warning.Print(warningLogLevel)
+	if Options.Minimal {
+		return convert.Minimal(obj)
 	}
+
+	return obj, nil
+}
+
+// toDeploy converts dc and moves it to the target namespace and registries.
+func toDeploy(dc *ocappsv1.DeploymentConfig) (*appsv1.Deployment, convert.Provenance, error) {
+	deploy, p, err := convert.ToDeployWithProvenance(dc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to convert to deploy: %w", err)
+	}
+
+	convert.Relocate(deploy, &p, Options.TargetNamespace, Options.RegistryRewrites)
+
+	return deploy, p, nil
 }
@@ -0,0 +1,419 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	triggersDisabledStep  = "TriggersDisabled"
+	deploymentCreatedStep = "DeploymentCreated"
+	deploymentReadyStep   = "DeploymentAvailable"
+	retargetedStep        = "ServicesRetargeted"
+	dcScaledDownStep      = "DeploymentConfigScaledDown"
+	dcDeletedStep         = "DeploymentConfigDeleted"
+)
+
+// cutoverState is stored on the DeploymentConfig in the
+// CutoverStateAnnotationKey annotation so an interrupted cutover can resume,
+// and so a failed one can restore the DeploymentConfig.
+type cutoverState struct {
+	Step       string                             `json:"step,omitempty"`
+	Replicas   int32                              `json:"replicas"`
+	Triggers   ocappsv1.DeploymentTriggerPolicies `json:"triggers"`
+	Retargeted []*k8s.Retargeted                  `json:"retargeted,omitempty"`
+}
+
+type cutoverStep struct {
+	name string
+	run  func(*cutover) error
+}
+
+type cutover struct {
+	dc     *ocappsv1.DeploymentConfig
+	obj    runtime.Object
+	state  *cutoverState
+	steps  []cutoverStep
+	resume bool
+}
+
+// DoCutover migrates a live DeploymentConfig to a Deployment. It disables the
+// DeploymentConfig triggers, creates the Deployment, waits for it to become
+// available, points the Services and HorizontalPodAutoscalers of the
+// DeploymentConfig at it, scales the DeploymentConfig to zero and optionally
+// deletes it.
+// Completed steps are recorded on the DeploymentConfig so the cutover can be
+// re-run after an interruption. A failed step rolls back the earlier ones.
+func DoCutover() error {
+	if err := initClient(); err != nil {
+		return err
+	}
+
+	dc, err := source.LoadDC(Options.LiveDC, Options.LiveNamespace)
+	if err != nil {
+		return fmt.Errorf("unable to load %s: %w", Options.LiveDC, err)
+	}
+
+	c := &cutover{
+		dc: dc,
+		steps: []cutoverStep{
+			{triggersDisabledStep, (*cutover).disableTriggers},
+			{deploymentCreatedStep, (*cutover).createDeployment},
+			{deploymentReadyStep, (*cutover).waitForDeployment},
+			{retargetedStep, (*cutover).retarget},
+			{dcScaledDownStep, (*cutover).scaleDown},
+		},
+	}
+
+	if Options.CutoverDelete {
+		c.steps = append(c.steps, cutoverStep{dcDeletedStep, (*cutover).deleteDC})
+	}
+
+	if err := c.loadState(); err != nil {
+		return err
+	}
+
+	if !c.resume {
+		if Options.LiveWaitStable > 0 {
+			c.dc, err = source.WaitForStableDC(dc.Name, dc.Namespace, Options.LiveWaitStable)
+			if err != nil {
+				return err
+			}
+		}
+
+		warnings, blocking, err := evaluateWarnings(c.dc)
+		if err != nil {
+			return err
+		}
+
+		if err := checkWarnings(warnings, blocking); err != nil {
+			return err
+		}
+	}
+
+	if err := c.convert(); err != nil {
+		return err
+	}
+
+	if !c.resume {
+		targeting, err := target.Targeting(c.dc, targetNamespace(c.dc))
+		if err != nil {
+			return err
+		}
+
+		if err := writeBackup(c.dc, c.obj, targeting...); err != nil {
+			return err
+		}
+	}
+
+	return c.run()
+}
+
+func (c *cutover) loadState() error {
+	s, ok := c.dc.Annotations[convert.CutoverStateAnnotationKey]
+	if !ok {
+		c.state = &cutoverState{
+			Replicas: c.dc.Spec.Replicas,
+			Triggers: c.dc.Spec.Triggers,
+		}
+
+		return nil
+	}
+
+	c.state = &cutoverState{}
+	if err := json.Unmarshal([]byte(s), c.state); err != nil {
+		return fmt.Errorf("unable to parse cutover state of %s: %w", c.dc.Name, err)
+	}
+
+	c.resume = true
+
+	writer.WriteErr(0, "cutover %s: resuming after step %s", c.dc.Name, c.state.Step)
+
+	return nil
+}
+
+// convert builds the Deployment from the DeploymentConfig as it was before
+// the cutover changed its replicas and triggers.
+func (c *cutover) convert() error {
+	dc := c.dc.DeepCopy()
+	dc.Spec.Replicas = c.state.Replicas
+	dc.Spec.Triggers = c.state.Triggers
+	delete(dc.Annotations, convert.CutoverStateAnnotationKey)
+
+	obj, err := convertDC(dc)
+	if err != nil {
+		return err
+	}
+
+	objs, err := k8s.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objs {
+		if o.GetKind() == "Deployment" {
+			annotations := o.GetAnnotations()
+			annotations[convert.CutoverFromAnnotationKey] = dc.Name
+			o.SetAnnotations(annotations)
+		}
+	}
+
+	c.obj, err = convert.ToList(toObjects(objs)...)
+
+	return err
+}
+
+func (c *cutover) run() error {
+	done := c.state.Step == ""
+
+	for i, step := range c.steps {
+		if !done {
+			done = step.name == c.state.Step
+			continue
+		}
+
+		writer.WriteErr(0, "cutover %s: [%d/%d] %s", c.dc.Name, i+1, len(c.steps), step.name)
+
+		if err := step.run(c); err != nil {
+			writer.WriteErr(0, "cutover %s: %s failed: %v", c.dc.Name, step.name, err)
+
+			if rerr := c.rollback(); rerr != nil {
+				return fmt.Errorf("cutover failed: %w (rollback failed: %v)", err, rerr)
+			}
+
+			return fmt.Errorf("cutover failed and was rolled back: %w", err)
+		}
+
+		c.state.Step = step.name
+
+		if step.name != dcDeletedStep {
+			if err := c.saveState(); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.WriteErr(0, "cutover %s: complete", c.dc.Name)
+
+	return nil
+}
+
+func (c *cutover) saveState() error {
+	s, err := json.Marshal(c.state)
+	if err != nil {
+		return fmt.Errorf("unable to marshal cutover state: %w", err)
+	}
+
+	return c.patch(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				convert.CutoverStateAnnotationKey: string(s),
+			},
+		},
+	})
+}
+
+func (c *cutover) patch(p map[string]interface{}) error {
+	patch, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("unable to marshal patch: %w", err)
+	}
+
+	dc, err := source.PatchDC(c.dc.Name, c.dc.Namespace, patch)
+	if err != nil {
+		return err
+	}
+
+	c.dc = dc
+
+	return nil
+}
+
+func (c *cutover) disableTriggers() error {
+	s, err := json.Marshal(c.state)
+	if err != nil {
+		return fmt.Errorf("unable to marshal cutover state: %w", err)
+	}
+
+	return c.patch(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				convert.CutoverStateAnnotationKey: string(s),
+			},
+		},
+		"spec": map[string]interface{}{
+			"triggers": []interface{}{},
+		},
+	})
+}
+
+// createDeployment applies the Deployment, so it also succeeds when resuming a
+// cutover that was interrupted after the Deployment was created. An existing
+// Deployment that no cutover of the DeploymentConfig created is never adopted,
+// as rollback would delete it.
+func (c *cutover) createDeployment() error {
+	deploy, err := target.GetDeployment(c.dc.Name, targetNamespace(c.dc))
+
+	switch {
+	case err == nil && deploy.Annotations[convert.CutoverFromAnnotationKey] != c.dc.Name:
+		return fmt.Errorf("deployment %s/%s already exists and was not created by a cutover of %s",
+			targetNamespace(c.dc), c.dc.Name, c.dc.Name)
+	case err != nil && !apierrors.IsNotFound(err):
+		return err
+	}
+
+	_, err = target.Apply(c.obj, Options.ForceConflicts, false)
+
+	return err
+}
+
+func (c *cutover) waitForDeployment() error {
+	return target.WaitForDeployment(c.dc.Name, targetNamespace(c.dc), Options.WaitTimeout)
+}
+
+// retarget points the Services and HorizontalPodAutoscalers of the
+// DeploymentConfig at the Deployment before it is scaled down, so they do not
+// lose their endpoints. What changed is saved for rollback even if it fails.
+func (c *cutover) retarget() error {
+	retargeted, err := target.Retarget(c.dc, targetNamespace(c.dc))
+	c.state.Retargeted = append(c.state.Retargeted, retargeted...)
+
+	if err != nil {
+		if serr := c.saveState(); serr != nil {
+			writer.WriteErr(0, "cutover %s: unable to save state: %v", c.dc.Name, serr)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (c *cutover) scaleDown() error {
+	return c.patch(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": 0,
+		},
+	})
+}
+
+func (c *cutover) deleteDC() error {
+	if Options.CutoverGracePeriod > 0 {
+		writer.WriteErr(0, "cutover %s: waiting %s before deleting", c.dc.Name, Options.CutoverGracePeriod)
+		time.Sleep(Options.CutoverGracePeriod)
+	}
+
+	return source.DeleteDC(c.dc.Name, c.dc.Namespace)
+}
+
+// rollback restores the DeploymentConfig replicas and triggers and waits for
+// it to be available before it restores the Services and
+// HorizontalPodAutoscalers and removes the Deployment and stash ConfigMap the
+// cutover created, so the application keeps running pods throughout. The
+// cutover state is cleared last, so a failed rollback can be retried.
+func (c *cutover) rollback() error {
+	writer.WriteErr(0, "cutover %s: rolling back", c.dc.Name)
+
+	err := c.patch(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": c.state.Replicas,
+			"triggers": c.state.Triggers,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := source.WaitForStableDC(c.dc.Name, c.dc.Namespace, Options.WaitTimeout); err != nil {
+		return err
+	}
+
+	if err := target.Restore(c.state.Retargeted); err != nil {
+		return err
+	}
+
+	deploy, err := target.GetDeployment(c.dc.Name, targetNamespace(c.dc))
+
+	switch {
+	case err == nil && deploy.Annotations[convert.CutoverFromAnnotationKey] == c.dc.Name:
+		if err := target.DeleteDeployment(c.dc.Name, targetNamespace(c.dc)); err != nil {
+			return err
+		}
+	case err != nil && !apierrors.IsNotFound(err):
+		return err
+	}
+
+	if err := c.deleteStash(); err != nil {
+		return err
+	}
+
+	return c.patch(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				convert.CutoverStateAnnotationKey: nil,
+			},
+		},
+	})
+}
+
+// deleteStash deletes the stash ConfigMap the cutover created with the
+// Deployment, if any.
+func (c *cutover) deleteStash() error {
+	objs, err := k8s.ToUnstructured(c.obj)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objs {
+		if o.GetKind() != "ConfigMap" || o.GetName() != c.dc.Name+convert.StashConfigMapSuffix {
+			continue
+		}
+
+		if err := target.Delete(o); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func toObjects(objs []*unstructured.Unstructured) []runtime.Object {
+	result := make([]runtime.Object, 0, len(objs))
+
+	for _, o := range objs {
+		result = append(result, o)
+	}
+
+	return result
+}
@@ -0,0 +1,330 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+// newTestCutover returns a cutover of the DeploymentConfig app in a fake
+// cluster, as left by a cutover that reached step. The Deployment carries the
+// cutover annotation if owned is set.
+func newTestCutover(t *testing.T, step string, owned bool) (*cutover, *fake.FakeDynamicClient) {
+	t.Helper()
+
+	state := &cutoverState{
+		Step:     step,
+		Replicas: 2,
+		Triggers: ocappsv1.DeploymentTriggerPolicies{{Type: ocappsv1.DeploymentTriggerOnConfigChange}},
+		Retargeted: []*k8s.Retargeted{{
+			APIVersion: "v1",
+			Kind:       "Service",
+			Namespace:  "ns",
+			Name:       "app",
+			Field:      []string{"spec", "selector"},
+			From:       map[string]interface{}{"deploymentconfig": "app"},
+			To:         map[string]interface{}{"app": "app"},
+		}},
+	}
+
+	s, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.openshift.io/v1",
+		"kind":       "DeploymentConfig",
+		"metadata": map[string]interface{}{
+			"name":        "app",
+			"namespace":   "ns",
+			"annotations": map[string]interface{}{convert.CutoverStateAnnotationKey: string(s)},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(0),
+			"triggers": []interface{}{},
+		},
+		"status": map[string]interface{}{
+			"updatedReplicas":   int64(2),
+			"availableReplicas": int64(2),
+		},
+	}}
+
+	deploy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "app",
+			"namespace": "ns",
+		},
+	}}
+
+	if owned {
+		deploy.SetAnnotations(map[string]string{convert.CutoverFromAnnotationKey: "app"})
+	}
+
+	stash := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "app" + convert.StashConfigMapSuffix,
+			"namespace": "ns",
+		},
+	}}
+
+	service := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":      "app",
+			"namespace": "ns",
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"app": "app"},
+		},
+	}}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), dc, deploy.DeepCopy(), stash.DeepCopy(), service)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Service"},
+		{Version: "v1", Kind: "ConfigMap"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+	} {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+
+	oldOptions, oldSource, oldTarget := Options, source, target
+
+	t.Cleanup(func() {
+		Options, source, target = oldOptions, oldSource, oldTarget
+	})
+
+	Options = &CommandOptions{WaitTimeout: time.Second}
+	source = &k8s.Cluster{Client: client, Mapper: mapper, Namespace: "ns"}
+	target = source
+
+	obj, err := convert.ToList(deploy, stash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &cutover{obj: obj}
+
+	c.dc, err = source.LoadDC("app", "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.loadState(); err != nil {
+		t.Fatal(err)
+	}
+
+	client.ClearActions()
+
+	return c, client
+}
+
+// actions returns the verb and resource of the requests made to client.
+func actions(client *fake.FakeDynamicClient) []string {
+	var result []string
+
+	for _, a := range client.Actions() {
+		result = append(result, a.GetVerb()+" "+a.GetResource().Resource)
+	}
+
+	return result
+}
+
+func TestCutoverRollback(t *testing.T) {
+	tests := []struct {
+		name    string
+		owned   bool
+		actions []string
+	}{
+		{
+			name:  "created deployment",
+			owned: true,
+			actions: []string{
+				"patch deploymentconfigs",
+				"get deploymentconfigs",
+				"patch services",
+				"get deployments",
+				"delete deployments",
+				"delete configmaps",
+				"patch deploymentconfigs",
+			},
+		},
+		{
+			name:  "existing deployment",
+			owned: false,
+			actions: []string{
+				"patch deploymentconfigs",
+				"get deploymentconfigs",
+				"patch services",
+				"get deployments",
+				"delete configmaps",
+				"patch deploymentconfigs",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, client := newTestCutover(t, dcScaledDownStep, tt.owned)
+
+			if err := c.rollback(); err != nil {
+				t.Fatalf("rollback() error = %v", err)
+			}
+
+			if got := actions(client); !reflect.DeepEqual(got, tt.actions) {
+				t.Errorf("rollback() made requests\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.actions, "\n"))
+			}
+
+			dc, err := source.LoadDC("app", "ns")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := dc.Annotations[convert.CutoverStateAnnotationKey]; ok || dc.Spec.Replicas != 2 || len(dc.Spec.Triggers) != 1 {
+				t.Errorf("rollback() left dc replicas %d, triggers %v, annotations %v", dc.Spec.Replicas, dc.Spec.Triggers, dc.Annotations)
+			}
+
+			_, err = target.GetDeployment("app", "ns")
+			if exists := err == nil; exists == tt.owned {
+				t.Errorf("rollback() left deployment: %v, want %v", exists, !tt.owned)
+			}
+
+			svc, err := target.Get(&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]interface{}{"name": "app", "namespace": "ns"},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector"); !reflect.DeepEqual(selector, map[string]string{"deploymentconfig": "app"}) {
+				t.Errorf("rollback() left service selector %v", selector)
+			}
+		})
+	}
+}
+
+func TestCutoverRun(t *testing.T) {
+	errStep := errors.New("step failed")
+
+	tests := []struct {
+		name  string
+		step  string
+		fail  string
+		ran   []string
+		saved string
+		err   bool
+	}{
+		{
+			name:  "all steps",
+			ran:   []string{triggersDisabledStep, deploymentCreatedStep, deploymentReadyStep, retargetedStep, dcScaledDownStep},
+			saved: dcScaledDownStep,
+		},
+		{
+			name:  "resume",
+			step:  deploymentReadyStep,
+			ran:   []string{retargetedStep, dcScaledDownStep},
+			saved: dcScaledDownStep,
+		},
+		{
+			name: "failed step",
+			fail: retargetedStep,
+			ran:  []string{triggersDisabledStep, deploymentCreatedStep, deploymentReadyStep, retargetedStep},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestCutover(t, tt.step, true)
+
+			var ran []string
+
+			for _, name := range []string{triggersDisabledStep, deploymentCreatedStep, deploymentReadyStep, retargetedStep, dcScaledDownStep} {
+				name := name
+
+				c.steps = append(c.steps, cutoverStep{name, func(*cutover) error {
+					ran = append(ran, name)
+
+					if name == tt.fail {
+						return errStep
+					}
+
+					return nil
+				}})
+			}
+
+			err := c.run()
+			if (err != nil) != tt.err || (err != nil && !errors.Is(err, errStep)) {
+				t.Fatalf("run() error = %v, want error %v", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(ran, tt.ran) {
+				t.Errorf("run() ran %v, want %v", ran, tt.ran)
+			}
+
+			dc, err := source.LoadDC("app", "ns")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var saved string
+
+			if s, ok := dc.Annotations[convert.CutoverStateAnnotationKey]; ok {
+				state := &cutoverState{}
+
+				if err := json.Unmarshal([]byte(s), state); err != nil {
+					t.Fatal(err)
+				}
+
+				saved = state.Step
+			}
+
+			if saved != tt.saved {
+				t.Errorf("run() saved step %q, want %q", saved, tt.saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	templatev1 "github.com/openshift/api/template/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DoDiff writes the fields of a DeploymentConfig that change in its
+// conversion, next to the Deployment on the cluster in live mode. Each
+// DeploymentConfig of a Template is diffed in turn, with its parameter
+// references as they are.
+func DoDiff() error {
+	if Options.inputType == LiveIOType {
+		if err := initClient(); err != nil {
+			return err
+		}
+
+		dc, err := source.LoadDC(Options.LiveDC, Options.LiveNamespace)
+		if err != nil {
+			return fmt.Errorf("unable to load %s: %w", Options.LiveDC, err)
+		}
+
+		return diffDC(dc, nil)
+	}
+
+	obj, err := convert.Load(Options.Filename)
+	if err != nil {
+		return fmt.Errorf("unable to load %s: %w", Options.Filename, err)
+	}
+
+	switch t := obj.(type) {
+	case *templatev1.Template:
+		return diffTemplate(t)
+	case *ocappsv1.DeploymentConfig:
+		if t.Kind != "DeploymentConfig" {
+			return fmt.Errorf("%s is a %s, not a DeploymentConfig or Template", Options.Filename, t.Kind)
+		}
+
+		return diffDC(t, nil)
+	}
+
+	return fmt.Errorf("%s is not a DeploymentConfig or Template", Options.Filename)
+}
+
+// diffTemplate writes the diff of each DeploymentConfig of t under its name,
+// as a table each or as one JSON object.
+func diffTemplate(t *templatev1.Template) error {
+	var (
+		b     bytes.Buffer
+		diffs = map[string][]*convert.FieldDiff{}
+	)
+
+	for _, raw := range t.Objects {
+		dc, p, err := convert.TemplateDC(raw)
+		if err != nil {
+			return err
+		}
+
+		if dc == nil {
+			continue
+		}
+
+		diff, _, err := fieldDiff(dc, p)
+		if err != nil {
+			return err
+		}
+
+		diffs[dc.Name] = diff
+
+		if Options.DiffFormat != JSONReportFormat {
+			o, err := diffTable(diff, false)
+			if err != nil {
+				return err
+			}
+
+			if b.Len() != 0 {
+				b.WriteString("\n")
+			}
+
+			fmt.Fprintf(&b, "DEPLOYMENTCONFIG %s\n", dc.Name)
+			b.Write(o)
+		}
+	}
+
+	if len(diffs) == 0 {
+		return fmt.Errorf("no deploymentconfig found in %s", Options.Filename)
+	}
+
+	if Options.DiffFormat == JSONReportFormat {
+		o, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal diff: %w", err)
+		}
+
+		return writer.WriteFile(Options.OutputFilename, o)
+	}
+
+	return writer.WriteFile(Options.OutputFilename, b.Bytes())
+}
+
+// diffDC writes the diff of dc, next to the Deployment on the cluster in live
+// mode.
+func diffDC(dc *ocappsv1.DeploymentConfig, p *convert.Placeholders) error {
+	diff, live, err := fieldDiff(dc, p)
+	if err != nil {
+		return err
+	}
+
+	var o []byte
+
+	switch Options.DiffFormat {
+	case JSONReportFormat:
+		o, err = json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal diff: %w", err)
+		}
+	default:
+		o, err = diffTable(diff, live != nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.WriteFile(Options.OutputFilename, o)
+}
+
+// fieldDiff returns the diff of dc, and the Deployment on the cluster it is
+// compared with in live mode, restoring the parameter references of p in its
+// values if it comes from a Template.
+func fieldDiff(dc *ocappsv1.DeploymentConfig, p *convert.Placeholders) ([]*convert.FieldDiff, *appsv1.Deployment, error) {
+	var live *appsv1.Deployment
+
+	deploy, prov, err := toDeploy(dc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if Options.inputType == LiveIOType {
+		live, err = target.GetDeployment(deploy.Name, deploy.Namespace)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, nil, err
+		}
+	}
+
+	diff, err := convert.Diff(dc, deploy, prov, live)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to diff %s: %w", dc.Name, err)
+	}
+
+	if p != nil {
+		for _, d := range diff {
+			d.From, d.To = p.RestoreValue(d.From), p.RestoreValue(d.To)
+		}
+	}
+
+	return diff, live, nil
+}
+
+// diffTable aligns the fields by column, with a cluster column if live.
+func diffTable(diff []*convert.FieldDiff, live bool) ([]byte, error) {
+	var b bytes.Buffer
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	if live {
+		fmt.Fprintln(tw, "SOURCE\tPATH\tTRANSFORM\tDEPLOYMENTCONFIG\tDEPLOYMENT\tCLUSTER\tNOTE")
+	} else {
+		fmt.Fprintln(tw, "SOURCE\tPATH\tTRANSFORM\tDEPLOYMENTCONFIG\tDEPLOYMENT\tNOTE")
+	}
+
+	for _, d := range diff {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t", orDash(d.Source), orDash(d.Path), orDash(string(d.Transform)), orDash(d.From), orDash(d.To))
+
+		if live {
+			fmt.Fprintf(tw, "%s\t", orDash(*d.Live))
+		}
+
+		fmt.Fprintf(tw, "%s\n", orDash(d.Note))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return nil, fmt.Errorf("unable to render diff: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"errors"
+
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+)
+
+const (
+	ErrorExitCode                    = 1
+	WaitTimeoutExitCode              = 2
+	ProgressDeadlineExceededExitCode = 3
+)
+
+// ExitCode returns the process exit code for err, so callers can tell a
+// rollout that ran out of time from one the cluster reported as failed.
+func ExitCode(err error) int {
+	switch {
+	case errors.Is(err, k8s.ErrWaitTimeout):
+		return WaitTimeoutExitCode
+	case errors.Is(err, k8s.ErrProgressDeadlineExceeded):
+		return ProgressDeadlineExceededExitCode
+	default:
+		return ErrorExitCode
+	}
+}
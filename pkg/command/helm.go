@@ -0,0 +1,128 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/helm"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	templatev1 "github.com/openshift/api/template/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// chartObjects returns the objects of obj, the conversion of dc, followed by
+// the hook Jobs of dc when they are enabled.
+func chartObjects(dc *ocappsv1.DeploymentConfig, obj runtime.Object) ([]interface{}, error) {
+	objs, err := k8s.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []interface{}
+
+	for _, o := range objs {
+		result = append(result, o)
+	}
+
+	jobs, err := hookJobs(dc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, j := range jobs {
+		result = append(result, j)
+	}
+
+	return result, nil
+}
+
+// hookJobs returns the Jobs running the lifecycle hooks of dc as Helm hooks, or
+// nil unless they are enabled.
+func hookJobs(dc *ocappsv1.DeploymentConfig) ([]runtime.Object, error) {
+	if !Options.HelmHooks {
+		return nil, nil
+	}
+
+	jobs, err := helm.HookJobs(dc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert hooks of %s: %w", dc.Name, err)
+	}
+
+	var result []runtime.Object
+
+	for _, j := range jobs {
+		result = append(result, j)
+	}
+
+	return result, nil
+}
+
+// writeChart writes objs as a Helm chart to the HelmChart directory, with the
+// parameters of t as values when converting a Template.
+func writeChart(t *templatev1.Template, objs []interface{}) error {
+	c := helm.NewChart(filepath.Base(Options.HelmChart))
+
+	if t != nil {
+		c.SetTemplate(t)
+	}
+
+	for _, o := range objs {
+		if err := c.Add(o); err != nil {
+			return fmt.Errorf("unable to add object to chart: %w", err)
+		}
+	}
+
+	if err := c.Write(Options.HelmChart); err != nil {
+		return fmt.Errorf("unable to write chart %s: %w", Options.HelmChart, err)
+	}
+
+	writer.WriteErr(1, "wrote chart %s", Options.HelmChart)
+
+	return nil
+}
+
+// withoutHelmHooks drops the warnings about execNewPod hooks when they become
+// Helm hook Jobs.
+func withoutHelmHooks(w []*convert.Warning) []*convert.Warning {
+	if !Options.HelmHooks {
+		return w
+	}
+
+	var result []*convert.Warning
+
+	for _, warning := range w {
+		if warning.Code == convert.UnsupportedFeatureHooksWarning.Code && strings.HasSuffix(warning.Path, ".execNewPod.containerName") {
+			continue
+		}
+
+		result = append(result, warning)
+	}
+
+	return result
+}
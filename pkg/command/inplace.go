@@ -0,0 +1,105 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	"github.com/csfreak/dc2deploy/pkg/report"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// convertInPlace converts every DeploymentConfig document of the input file
+// and writes the file back, leaving the other documents as they are.
+func convertInPlace() error {
+	m, err := convert.LoadManifest(Options.Filename)
+	if err != nil {
+		return fmt.Errorf("unable to load %s: %w", Options.Filename, err)
+	}
+
+	var results []*report.Result
+
+	for i := 0; i < m.Len(); i++ {
+		dc, err := m.DeploymentConfig(i)
+		if err != nil {
+			return err
+		}
+
+		if dc == nil {
+			continue
+		}
+
+		warnings, blocking, err := evaluateWarnings(dc)
+		if err != nil {
+			return err
+		}
+
+		results = append(results, warningsResult(dc, warnings))
+
+		if Options.WarningsFormat != "" {
+			continue
+		}
+
+		if err := checkWarnings(warnings, blocking); err != nil {
+			return err
+		}
+
+		obj, err := convertDC(dc)
+		if err != nil {
+			return err
+		}
+
+		objs, err := k8s.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+
+		var replacement []runtime.Object
+
+		for _, o := range objs {
+			replacement = append(replacement, o)
+		}
+
+		if err := m.Replace(i, replacement...); err != nil {
+			return fmt.Errorf("unable to replace %s: %w", dc.Name, err)
+		}
+	}
+
+	if results == nil {
+		return fmt.Errorf("no deploymentconfig found in %s", Options.Filename)
+	}
+
+	if Options.WarningsFormat != "" {
+		return writeWarnings(results...)
+	}
+
+	o, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteFile(Options.Filename, o)
+}
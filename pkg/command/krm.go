@@ -0,0 +1,254 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	"github.com/csfreak/dc2deploy/pkg/krm"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	dcGroupKind  = schema.GroupKind{Group: ocappsv1.GroupName, Kind: "DeploymentConfig"}
+	svcGroupKind = schema.GroupKind{Kind: "Service"}
+	hpaGroupKind = schema.GroupKind{Group: "autoscaling", Kind: "HorizontalPodAutoscaler"}
+)
+
+// DoKRM runs dc2deploy as a KRM function. It converts every DeploymentConfig
+// in the ResourceList read from in, rewrites the Services and
+// HorizontalPodAutoscalers of the list using them, and writes the list back
+// with the warnings as results. DeploymentConfigs blocked by warnings are left
+// as they are and fail the function.
+func DoKRM(in io.Reader) error {
+	l, err := krm.Read(in)
+	if err != nil {
+		return err
+	}
+
+	opts, err := l.Options()
+	if err != nil {
+		return err
+	}
+
+	if err := setFunctionConfig(opts); err != nil {
+		return err
+	}
+
+	var (
+		items     []*unstructured.Unstructured
+		converted []*ocappsv1.DeploymentConfig
+		blocked   int
+	)
+
+	for _, u := range l.Items {
+		if u.GroupVersionKind().GroupKind() != dcGroupKind {
+			items = append(items, u)
+			continue
+		}
+
+		dc := &ocappsv1.DeploymentConfig{}
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, dc); err != nil {
+			return fmt.Errorf("unable to parse deploymentconfig %s: %w", u.GetName(), err)
+		}
+
+		warnings, blocking, err := evaluate(dc, false)
+		if err != nil {
+			return err
+		}
+
+		l.Results = append(l.Results, warningResults(u, warnings)...)
+
+		if blocking != nil {
+			blocked++
+
+			items = append(items, u)
+
+			continue
+		}
+
+		obj, err := convertDC(dc)
+		if err != nil {
+			return err
+		}
+
+		objs, err := k8s.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+
+		items = append(items, objs...)
+		converted = append(converted, dc)
+	}
+
+	if err := rewriteRelated(items, converted); err != nil {
+		return err
+	}
+
+	l.Items = items
+
+	o, err := l.Marshal()
+	if err != nil {
+		return fmt.Errorf("unable to marshal resource list: %w", err)
+	}
+
+	if err := writer.WriteFile("-", o); err != nil {
+		return err
+	}
+
+	if blocked != 0 {
+		return fmt.Errorf("blocked %d deploymentconfigs: set ignore-warnings or allow-warning in the functionConfig to continue", blocked)
+	}
+
+	return nil
+}
+
+// setFunctionConfig sets the options given in the functionConfig, named like
+// the matching flags.
+func setFunctionConfig(opts map[string]string) error {
+	for k, v := range opts {
+		switch k {
+		case "target-namespace":
+			Options.TargetNamespace = v
+		case "registry-rewrite":
+			rewrites := map[string]string{}
+
+			for _, r := range splitList(v) {
+				from, to, ok := strings.Cut(r, "=")
+				if !ok {
+					return fmt.Errorf("invalid registry-rewrite %s (use old=new)", r)
+				}
+
+				rewrites[from] = to
+			}
+
+			Options.RegistryRewrites = rewrites
+		case "stash":
+			switch mode := convert.StashMode(v); mode {
+			case convert.NoStash, convert.AnnotationStash, convert.ConfigMapStash:
+				Options.Stash = mode
+			default:
+				return fmt.Errorf("unknown stash mode: %s (use annotation or configmap)", v)
+			}
+		case "ignore-warnings":
+			ignore, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid ignore-warnings %s: %w", v, err)
+			}
+
+			Options.IgnoreWarnings = ignore
+		case "minimal":
+			minimal, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid minimal %s: %w", v, err)
+			}
+
+			Options.Minimal = minimal
+		case "allow-warning":
+			Options.AllowWarnings = splitList(v)
+		case "deny-warning":
+			Options.DenyWarnings = splitList(v)
+		default:
+			return fmt.Errorf("unknown functionConfig option: %s", k)
+		}
+	}
+
+	return nil
+}
+
+// warningResults returns w, raised for the DeploymentConfig u, as results.
+// Warnings that block are errors and info warnings are info results.
+func warningResults(u *unstructured.Unstructured, w []*convert.Warning) []*krm.Result {
+	var results []*krm.Result
+
+	for _, warning := range w {
+		var severity krm.Severity
+
+		switch {
+		case !warning.Allowed:
+			severity = krm.ErrorSeverity
+		case warning.Severity == convert.InfoSeverity:
+			severity = krm.InfoSeverity
+		default:
+			severity = krm.WarningSeverity
+		}
+
+		message := fmt.Sprintf("%s [%s]: %s", warning.Name, warning.Code, warning.Description)
+		if warning.Value != "" {
+			message += fmt.Sprintf(" (%s)", warning.Value)
+		}
+
+		results = append(results, krm.NewResult(u, severity, warning.Path, message))
+	}
+
+	return results
+}
+
+// rewriteRelated points the Services and HorizontalPodAutoscalers in items
+// using the converted DeploymentConfigs at their Deployments.
+func rewriteRelated(items []*unstructured.Unstructured, converted []*ocappsv1.DeploymentConfig) error {
+	for _, u := range items {
+		for _, dc := range converted {
+			if u.GetNamespace() != dc.Namespace {
+				continue
+			}
+
+			var err error
+
+			switch u.GroupVersionKind().GroupKind() {
+			case svcGroupKind:
+				_, err = k8s.RewriteService(u, dc)
+			case hpaGroupKind:
+				_, err = k8s.RewriteHPA(u, dc)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var result []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/k8s"
+	"github.com/csfreak/dc2deploy/pkg/kustomize"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// writeKustomize writes obj, the conversion of the input, as the base of a
+// kustomize layout in the KustomizeDir directory, with an overlay for each
+// variant of the DeploymentConfig in Overlays.
+func writeKustomize(obj runtime.Object) error {
+	base, err := k8s.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	l := kustomize.NewLayout(base)
+
+	for name, filename := range Options.Overlays {
+		dc, err := convert.LoadDC(filename)
+		if err != nil {
+			return fmt.Errorf("unable to load %s: %w", filename, err)
+		}
+
+		// an overlay replaces the one DeploymentConfig of the base, so
+		// Templates and other objects cannot be one.
+		if dc.Kind != "DeploymentConfig" {
+			return fmt.Errorf("overlay %s: %s is not a DeploymentConfig", name, filename)
+		}
+
+		// Overlays are variants of the input, not objects of the cluster.
+		warnings, blocking, err := evaluate(dc, false)
+		if err != nil {
+			return err
+		}
+
+		if err := checkWarnings(warnings, blocking); err != nil {
+			return fmt.Errorf("overlay %s: %w", name, err)
+		}
+
+		obj, err := convertDC(dc)
+		if err != nil {
+			return err
+		}
+
+		objs, err := k8s.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+
+		l.AddOverlay(name, objs)
+	}
+
+	if err := l.Write(Options.KustomizeDir); err != nil {
+		return fmt.Errorf("unable to write kustomize layout %s: %w", Options.KustomizeDir, err)
+	}
+
+	writer.WriteErr(1, "wrote kustomize layout %s", Options.KustomizeDir)
+
+	return nil
+}
@@ -28,42 +28,186 @@ import (
 	"github.com/csfreak/dc2deploy/pkg/convert"
 	"github.com/csfreak/dc2deploy/pkg/k8s"
 	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// source is the cluster the DeploymentConfig is read from and target the one
+// the conversion is written to. They are the same unless a target context or
+// kubeconfig is given.
+var source, target *k8s.Cluster
+
 func DoLive() error {
-	err := k8s.Init(Options.LiveKubeconfig)
-	if err != nil {
-		return fmt.Errorf("unable to create kubernetes client: %w", err)
+	if err := initClient(); err != nil {
+		return err
 	}
 
-	dc, err := k8s.LoadDC(Options.LiveDC, Options.LiveNamespace)
+	dc, err := source.LoadDC(Options.LiveDC, Options.LiveNamespace)
 	if err != nil {
 		return fmt.Errorf("unable to create load %s: %w", Options.LiveDC, err)
 	}
 
-	warnings := convert.CheckFeatures(dc)
+	if Options.LiveWaitStable > 0 {
+		dc, err = source.WaitForStableDC(Options.LiveDC, Options.LiveNamespace, Options.LiveWaitStable)
+		if err != nil {
+			return err
+		}
+	}
+
+	warnings, blocking, err := evaluateWarnings(dc)
+	if err != nil {
+		return err
+	}
+
+	if Options.WarningsFormat != "" {
+		return writeWarnings(warningsResult(dc, warnings))
+	}
 
-	checkWarnings(warnings)
+	if err := checkWarnings(warnings, blocking); err != nil {
+		return err
+	}
 
-	if warnings != nil {
-		if !Options.IgnoreWarnings {
-			return fmt.Errorf("use --ignore-warnings to continue")
+	obj, err := convertDC(dc)
+	if err != nil {
+		return err
+	}
+
+	p := newPrinted(ConvertedAction)
+	p.warnings[dc.Name] = len(warnings)
+
+	if Options.Dependencies {
+		obj, err = bundle(dc, obj)
+		if err != nil {
+			return err
 		}
 	}
 
-	deploy, err := convert.ToDeploy(dc)
+	switch Options.LiveDryRun {
+	case ClientDryRun:
+		if Options.HelmChart != "" {
+			objs, err := chartObjects(dc, obj)
+			if err != nil {
+				return err
+			}
+
+			return writeChart(nil, objs)
+		}
+
+		if Options.KustomizeDir != "" {
+			return writeKustomize(obj)
+		}
+
+		return writeObject(obj, p)
+	case ServerDryRun:
+		objs, err := target.Apply(obj, Options.ForceConflicts, true)
+		if err != nil {
+			return err
+		}
+
+		p.action = ServerDryRunAction
+
+		return writeObject(fromUnstructured(objs), p)
+	}
+
+	if err := writeBackup(dc, obj); err != nil {
+		return err
+	}
+
+	objs, err := target.Apply(obj, Options.ForceConflicts, false)
 	if err != nil {
-		return fmt.Errorf("unable to convert to deploy: %w", err)
+		return err
+	}
+
+	switch Options.OutputFileType {
+	case "", YAMLFileType, JSONFileType:
+	default:
+		p.action = AppliedAction
+
+		if err := writeObject(fromUnstructured(objs), p); err != nil {
+			return err
+		}
 	}
 
-	if Options.LiveDryRun {
-		o, err := convert.ToOuput(deploy, string(Options.OutputFileType))
+	if Options.LiveWait {
+		return target.WaitForDeployment(dc.Name, targetNamespace(dc), Options.WaitTimeout)
+	}
+
+	return nil
+}
+
+// initClient connects to the source cluster, or loads the dump standing in for
+// it, and to the target cluster when it is configured separately, and defaults
+// the namespace to the one of the current source context.
+func initClient() error {
+	var err error
+
+	if Options.DumpDir != "" {
+		source, err = k8s.NewDumpCluster(Options.DumpDir)
+	} else {
+		source, err = k8s.NewCluster(Options.LiveConfig)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to create kubernetes client: %w", err)
+	}
+
+	target = source
+
+	if Options.TargetConfig != nil {
+		target, err = k8s.NewCluster(Options.TargetConfig)
 		if err != nil {
-			return fmt.Errorf("unable to marshal object: %w", err)
+			return fmt.Errorf("unable to create target kubernetes client: %w", err)
 		}
+	}
 
-		return writer.WriteFile("-", o)
+	if Options.LiveNamespace == "" {
+		Options.LiveNamespace = source.Namespace
 	}
 
 	return nil
 }
+
+// targetNamespace returns the namespace the conversion of dc is written to.
+func targetNamespace(dc *ocappsv1.DeploymentConfig) string {
+	if Options.TargetNamespace != "" {
+		return Options.TargetNamespace
+	}
+
+	return dc.Namespace
+}
+
+func writeObject(obj runtime.Object, p *printed) error {
+	var err error
+
+	if Options.Minimal {
+		obj, err = convert.Minimal(obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	o, err := output(obj, p)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteFile("-", o)
+}
+
+// fromUnstructured returns the only object of objs, or a List of them.
+func fromUnstructured(objs []*unstructured.Unstructured) runtime.Object {
+	if len(objs) == 1 {
+		return objs[0]
+	}
+
+	l := &unstructured.UnstructuredList{}
+	l.SetAPIVersion("v1")
+	l.SetKind("List")
+
+	for _, o := range objs {
+		l.Items = append(l.Items, *o)
+	}
+
+	return l
+}
@@ -24,34 +24,88 @@ package command
 
 import (
 	"fmt"
+	"path/filepath"
+	"time"
 
+	"github.com/csfreak/dc2deploy/pkg/convert"
 	"github.com/csfreak/dc2deploy/pkg/writer"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 var Options *CommandOptions
 
 type CommandOptions struct {
-	inputType      IOType   `default:"FileIOType"`
-	outputType     IOType   `default:"FileIOType"`
-	Filename       string   `default:""`
-	OutputFilename string   `default:""`
-	OutputFileType FileType `default:"YAMLFileType"`
-	LiveDryRun     bool     `default:"false"`
-	LiveNamespace  string   `default:""`
-	LiveDC         string   `default:""`
-	LiveKubeconfig string   `default:""`
-	IgnoreWarnings bool     `default:"false"`
-	Verbosity      uint8    `default:"0"`
+	inputType          IOType                             `default:"FileIOType"`
+	outputType         IOType                             `default:"FileIOType"`
+	Filename           string                             `default:""`
+	OutputFilename     string                             `default:""`
+	OutputFileType     FileType                           `default:"YAMLFileType"`
+	LiveDryRun         DryRunMode                         `default:""`
+	ForceConflicts     bool                               `default:"false"`
+	LiveNamespace      string                             `default:""`
+	LiveDC             string                             `default:""`
+	LiveConfig         genericclioptions.RESTClientGetter `default:""`
+	TargetConfig       genericclioptions.RESTClientGetter `default:""`
+	TargetNamespace    string                             `default:""`
+	RegistryRewrites   map[string]string                  `default:""`
+	Dependencies       bool                               `default:"false"`
+	IncludeSecrets     bool                               `default:"false"`
+	ScanNamespaces     []string                           `default:""`
+	ScanAllNamespaces  bool                               `default:"false"`
+	ScanFormat         ReportFormat                       `default:""`
+	DiffFormat         ReportFormat                       `default:""`
+	DumpDir            string                             `default:""`
+	LiveConfigSet      bool                               `default:"false"`
+	LiveWaitStable     time.Duration                      `default:"0"`
+	CutoverDelete      bool                               `default:"false"`
+	CutoverGracePeriod time.Duration                      `default:"0"`
+	LiveWait           bool                               `default:"false"`
+	WaitTimeout        time.Duration                      `default:"10m"`
+	BackupDir          string                             `default:"."`
+	BackupFilename     string                             `default:""`
+	RollbackScaleDown  bool                               `default:"false"`
+	IgnoreWarnings     bool                               `default:"false"`
+	AllowWarnings      []string                           `default:""`
+	DenyWarnings       []string                           `default:""`
+	ChecksFilename     string                             `default:""`
+	Stash              convert.StashMode                  `default:""`
+	Provenance         ReportFormat                       `default:""`
+	WarningsFormat     ReportFormat                       `default:""`
+	HelmChart          string                             `default:""`
+	HelmHooks          bool                               `default:"false"`
+	KustomizeDir       string                             `default:""`
+	Overlays           map[string]string                  `default:""`
+	InPlace            bool                               `default:"false"`
+	Minimal            bool                               `default:"false"`
+	Verbosity          uint8                              `default:"0"`
+
+	customChecks *convert.CustomChecks
 }
 
 type IOType string
 type FileType string
+type DryRunMode string
+
+const (
+	FileIOType   IOType     = "file"
+	LiveIOType   IOType     = "live"
+	BackupIOType IOType     = "backup"
+	JSONFileType FileType   = "json"
+	YAMLFileType FileType   = "yaml"
+	NoDryRun     DryRunMode = "none"
+	ClientDryRun DryRunMode = "client"
+	ServerDryRun DryRunMode = "server"
+)
 
 const (
-	FileIOType   IOType   = "file"
-	LiveIOType   IOType   = "live"
-	JSONFileType FileType = "json"
-	YAMLFileType FileType = "yaml"
+	NameFileType           FileType = "name"
+	TableFileType          FileType = "table"
+	WideFileType           FileType = "wide"
+	JSONPathFileType       FileType = "jsonpath"
+	JSONPathFileFileType   FileType = "jsonpath-file"
+	GoTemplateFileType     FileType = "go-template"
+	GoTemplateFileFileType FileType = "go-template-file"
+	CustomColumnsFileType  FileType = "custom-columns"
 )
 
 func SetCommandOptions(c *CommandOptions) error {
@@ -59,31 +113,144 @@ func SetCommandOptions(c *CommandOptions) error {
 		Options = &CommandOptions{}
 	}
 
-	if c.LiveDC != "" {
+	switch {
+	case c.DumpDir != "" && c.LiveConfigSet:
+		return fmt.Errorf("cannot specify from-dump and connection flags other than the target")
+	case c.BackupFilename != "":
+		if c.DumpDir != "" {
+			return fmt.Errorf("cannot roll back from a dump")
+		}
+
+		Options.BackupFilename = c.BackupFilename
+		Options.RollbackScaleDown = c.RollbackScaleDown
+		Options.WaitTimeout = c.WaitTimeout
+		Options.LiveConfig = c.LiveConfig
+		Options.TargetConfig = c.TargetConfig
+		Options.inputType = BackupIOType
+		Options.outputType = LiveIOType
+	case c.ScanFormat != "":
+		Options.ScanNamespaces = c.ScanNamespaces
+		Options.ScanAllNamespaces = c.ScanAllNamespaces
+		Options.ScanFormat = c.ScanFormat
+		Options.LiveNamespace = c.LiveNamespace
+		Options.LiveConfig = c.LiveConfig
+		Options.DumpDir = c.DumpDir
+		Options.OutputFilename = c.OutputFilename
+		Options.inputType = LiveIOType
+		Options.outputType = FileIOType
+
+		switch c.ScanFormat {
+		case TableReportFormat, CSVReportFormat, JSONReportFormat, HTMLReportFormat:
+		default:
+			return fmt.Errorf("unknown scan format: %s (use table, csv, json or html)", c.ScanFormat)
+		}
+
+		if c.ScanAllNamespaces && (len(c.ScanNamespaces) != 0 || c.LiveNamespace != "") {
+			return fmt.Errorf("cannot specify namespaces with all-namespaces")
+		}
+	case c.DiffFormat != "":
+		Options.DiffFormat = c.DiffFormat
+		Options.Filename = c.Filename
+		Options.LiveDC = c.LiveDC
+		Options.LiveNamespace = c.LiveNamespace
+		Options.LiveConfig = c.LiveConfig
+		Options.TargetConfig = c.TargetConfig
+		Options.DumpDir = c.DumpDir
+		Options.OutputFilename = c.OutputFilename
+		Options.inputType = FileIOType
+		Options.outputType = FileIOType
+
+		switch c.DiffFormat {
+		case TableReportFormat, JSONReportFormat:
+		default:
+			return fmt.Errorf("unknown diff format: %s (use table or json)", c.DiffFormat)
+		}
+
+		if c.LiveDC != "" {
+			Options.inputType = LiveIOType
+
+			if c.Filename != "" && c.Filename != "-" {
+				return fmt.Errorf("cannot specify filename with a deploymentconfig name")
+			}
+		} else if c.LiveConfigSet || c.TargetConfig != nil || c.LiveNamespace != "" || c.DumpDir != "" {
+			return fmt.Errorf("cannot specify input filename and live options")
+		}
+	case c.LiveDC != "":
 		Options.LiveDC = c.LiveDC
 		Options.LiveNamespace = c.LiveNamespace
-		Options.LiveKubeconfig = c.LiveKubeconfig
+		Options.LiveConfig = c.LiveConfig
+		Options.TargetConfig = c.TargetConfig
 		Options.LiveDryRun = c.LiveDryRun
+		Options.ForceConflicts = c.ForceConflicts
+		Options.LiveWaitStable = c.LiveWaitStable
+		Options.CutoverDelete = c.CutoverDelete
+		Options.CutoverGracePeriod = c.CutoverGracePeriod
+		Options.LiveWait = c.LiveWait
+		Options.WaitTimeout = c.WaitTimeout
+		Options.BackupDir = c.BackupDir
+		Options.Dependencies = c.Dependencies
+		Options.IncludeSecrets = c.IncludeSecrets
+		Options.DumpDir = c.DumpDir
 		Options.inputType = LiveIOType
 
-		if c.LiveDryRun {
+		// Without a target cluster, a conversion from a dump can only be
+		// printed.
+		if c.DumpDir != "" && c.TargetConfig == nil {
+			switch {
+			case c.LiveDryRun == NoDryRun:
+				c.LiveDryRun = ClientDryRun
+				Options.LiveDryRun = ClientDryRun
+			case c.LiveDryRun == ServerDryRun:
+				return fmt.Errorf("cannot specify dry-run=server with from-dump without a target cluster")
+			}
+		}
+
+		if c.DumpDir != "" && c.LiveWaitStable != 0 {
+			return fmt.Errorf("cannot specify wait-stable with from-dump")
+		}
+
+		if c.IncludeSecrets && !c.Dependencies {
+			return fmt.Errorf("cannot specify include-secrets without with-dependencies")
+		}
+
+		// A chart or kustomize layout is written from the local conversion.
+		if c.HelmChart != "" || c.KustomizeDir != "" {
+			switch c.LiveDryRun {
+			case NoDryRun, ClientDryRun:
+				c.LiveDryRun = ClientDryRun
+				Options.LiveDryRun = ClientDryRun
+			default:
+				return fmt.Errorf("cannot specify dry-run=%s with helm-chart or kustomize", c.LiveDryRun)
+			}
+		}
+
+		switch c.LiveDryRun {
+		case NoDryRun:
+			Options.outputType = LiveIOType
+		case ClientDryRun, ServerDryRun:
 			Options.outputType = FileIOType
 			Options.OutputFilename = "-"
-		} else {
-			Options.outputType = LiveIOType
+		default:
+			return fmt.Errorf("unknown dry-run mode: %s (use none, client or server)", c.LiveDryRun)
 		}
 
-		if c.Filename != "-" || c.OutputFilename != "-" {
+		if (c.Filename != "" && c.Filename != "-") || (c.OutputFilename != "" && c.OutputFilename != "-") {
 			return fmt.Errorf("cannot specify filename or outfile on live operation")
 		}
-	} else {
+	default:
 		Options.Filename = c.Filename
 		Options.inputType = FileIOType
 		Options.outputType = FileIOType
 
-		if c.LiveDryRun ||
-			c.LiveKubeconfig != "" ||
+		if c.LiveDryRun != NoDryRun ||
+			c.LiveConfigSet ||
+			c.TargetConfig != nil ||
 			c.LiveNamespace != "" ||
+			c.LiveWaitStable != 0 ||
+			c.LiveWait ||
+			c.Dependencies ||
+			c.IncludeSecrets ||
+			c.DumpDir != "" ||
 			c.LiveDC != "" {
 			return fmt.Errorf("cannot specify input filename and live options")
 		}
@@ -91,13 +258,99 @@ func SetCommandOptions(c *CommandOptions) error {
 		if c.OutputFilename != "" {
 			Options.OutputFilename = c.OutputFilename
 		}
+
+		if c.InPlace {
+			switch {
+			case c.Filename == "" || c.Filename == "-":
+				return fmt.Errorf("cannot specify in-place without filename")
+			case c.OutputFilename != "" && c.OutputFilename != "-":
+				return fmt.Errorf("cannot specify in-place and outfile")
+			case c.HelmChart != "" || c.KustomizeDir != "":
+				return fmt.Errorf("cannot specify in-place with helm-chart or kustomize")
+			}
+		}
+	}
+
+	if c.InPlace && Options.inputType != FileIOType {
+		return fmt.Errorf("cannot specify in-place on live operation")
+	}
+
+	Options.InPlace = c.InPlace
+	Options.Minimal = c.Minimal
+
+	if (c.HelmChart != "" || c.KustomizeDir != "") && c.OutputFilename != "" && c.OutputFilename != "-" {
+		return fmt.Errorf("cannot specify outfile with helm-chart or kustomize")
+	}
+
+	if c.HelmChart != "" && c.KustomizeDir != "" {
+		return fmt.Errorf("cannot specify helm-chart and kustomize")
+	}
+
+	if len(c.Overlays) != 0 && c.KustomizeDir == "" {
+		return fmt.Errorf("cannot specify overlay without kustomize")
+	}
+
+	for name := range c.Overlays {
+		if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+			return fmt.Errorf("invalid overlay name: %s", name)
+		}
 	}
 
-	if Options.outputType == FileIOType {
-		Options.OutputFileType = c.OutputFileType
+	if c.HelmHooks && c.HelmChart == "" {
+		return fmt.Errorf("cannot specify helm-hooks without helm-chart")
 	}
 
+	Options.HelmChart = c.HelmChart
+	Options.HelmHooks = c.HelmHooks
+	Options.KustomizeDir = c.KustomizeDir
+	Options.Overlays = c.Overlays
+
+	// A live conversion is printed once applied, unless as yaml or json.
+	Options.OutputFileType = c.OutputFileType
+
+	if c.OutputFileType != "" {
+		if _, err := newPrinter(c.OutputFileType, nil); err != nil {
+			return err
+		}
+	}
+
+	Options.TargetNamespace = c.TargetNamespace
+	Options.RegistryRewrites = c.RegistryRewrites
 	Options.IgnoreWarnings = c.IgnoreWarnings
+	Options.AllowWarnings = c.AllowWarnings
+	Options.DenyWarnings = c.DenyWarnings
+	Options.ChecksFilename = c.ChecksFilename
+
+	if c.ChecksFilename != "" {
+		checks, err := convert.LoadChecks(c.ChecksFilename)
+		if err != nil {
+			return fmt.Errorf("unable to load checks %s: %w", c.ChecksFilename, err)
+		}
+
+		Options.customChecks = checks
+	}
+
+	switch c.Stash {
+	case convert.NoStash, convert.AnnotationStash, convert.ConfigMapStash:
+		Options.Stash = c.Stash
+	default:
+		return fmt.Errorf("unknown stash mode: %s (use annotation or configmap)", c.Stash)
+	}
+
+	switch c.Provenance {
+	case "", TableReportFormat, JSONReportFormat:
+		Options.Provenance = c.Provenance
+	default:
+		return fmt.Errorf("unknown provenance format: %s (use table or json)", c.Provenance)
+	}
+
+	switch c.WarningsFormat {
+	case "", JSONReportFormat, YAMLReportFormat, SARIFReportFormat, JUnitReportFormat:
+		Options.WarningsFormat = c.WarningsFormat
+	default:
+		return fmt.Errorf("unknown warnings format: %s (use json, yaml, sarif or junit)", c.WarningsFormat)
+	}
+
 	Options.Verbosity = c.Verbosity
 
 	if Options.Verbosity > 4 {
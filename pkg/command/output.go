@@ -0,0 +1,374 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	ConvertedAction    = "converted"
+	AppliedAction      = "applied"
+	ServerDryRunAction = "applied (server dry run)"
+
+	none = "<none>"
+)
+
+// printed describes a conversion for the table formats: the action taken and
+// the number of warnings of each DeploymentConfig by name.
+type printed struct {
+	action   string
+	warnings map[string]int
+}
+
+func newPrinted(action string) *printed {
+	return &printed{
+		action:   action,
+		warnings: map[string]int{},
+	}
+}
+
+// output formats obj as the OutputFileType.
+func output(obj runtime.Object, p *printed) ([]byte, error) {
+	printer, err := newPrinter(Options.OutputFileType, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+
+	if err := printer.PrintObj(obj, &b); err != nil {
+		return nil, fmt.Errorf("unable to print object: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// newPrinter returns the printer for t. Besides yaml and json, these are the
+// kubectl name, jsonpath, go-template and custom-columns printers, and a table
+// of the converted objects with the DeploymentConfig each came from.
+func newPrinter(t FileType, p *printed) (printers.ResourcePrinter, error) {
+	format, arg, hasArg := strings.Cut(string(t), "=")
+
+	switch FileType(format) {
+	case YAMLFileType, JSONFileType:
+		return printers.ResourcePrinterFunc(func(obj runtime.Object, w io.Writer) error {
+			o, err := convert.ToOuput(obj, format)
+			if err != nil {
+				return err
+			}
+
+			_, err = w.Write(o)
+
+			return err
+		}), nil
+	case NameFileType:
+		return printers.ResourcePrinterFunc(func(obj runtime.Object, w io.Writer) error {
+			l, err := printItems(obj)
+			if err != nil {
+				return err
+			}
+
+			return (&printers.NamePrinter{}).PrintObj(l, w)
+		}), nil
+	case TableFileType, WideFileType:
+		return printers.ResourcePrinterFunc(func(obj runtime.Object, w io.Writer) error {
+			l, err := printItems(obj)
+			if err != nil {
+				return err
+			}
+
+			return printers.NewTablePrinter(printers.PrintOptions{Wide: FileType(format) == WideFileType}).PrintObj(p.table(l), w)
+		}), nil
+	}
+
+	if !hasArg || arg == "" {
+		return nil, fmt.Errorf("unknown output format: %s (use yaml, json, name, table, wide, jsonpath=, jsonpath-file=, go-template=, go-template-file= or custom-columns=)", t)
+	}
+
+	switch FileType(format) {
+	case JSONPathFileFileType, GoTemplateFileFileType:
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read template %s: %w", arg, err)
+		}
+
+		arg = string(data)
+	}
+
+	switch FileType(format) {
+	case JSONPathFileType, JSONPathFileFileType:
+		printer, err := printers.NewJSONPathPrinter(arg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse jsonpath %s: %w", arg, err)
+		}
+
+		printer.AllowMissingKeys(true)
+
+		return printer, nil
+	case GoTemplateFileType, GoTemplateFileFileType:
+		printer, err := printers.NewGoTemplatePrinter([]byte(arg))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse go-template %s: %w", arg, err)
+		}
+
+		return printer, nil
+	case CustomColumnsFileType:
+		columns, err := parseColumns(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return printers.ResourcePrinterFunc(func(obj runtime.Object, w io.Writer) error {
+			l, err := printItems(obj)
+			if err != nil {
+				return err
+			}
+
+			table, err := columns.table(l)
+			if err != nil {
+				return err
+			}
+
+			return printers.NewTablePrinter(printers.PrintOptions{}).PrintObj(table, w)
+		}), nil
+	}
+
+	return nil, fmt.Errorf("unknown output format: %s (use yaml, json, name, table, wide, jsonpath=, jsonpath-file=, go-template=, go-template-file= or custom-columns=)", t)
+}
+
+// printItems returns the items of obj, or the objects of a Template, as a List.
+func printItems(obj runtime.Object) (*unstructured.UnstructuredList, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal object: %w", err)
+	}
+
+	decoded, _, err := unstructured.UnstructuredJSONScheme.Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse object: %w", err)
+	}
+
+	if l, ok := decoded.(*unstructured.UnstructuredList); ok {
+		return l, nil
+	}
+
+	l := &unstructured.UnstructuredList{}
+	l.SetAPIVersion("v1")
+	l.SetKind("List")
+
+	u, ok := decoded.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unable to print %T", decoded)
+	}
+
+	if u.GetKind() != "Template" {
+		l.Items = append(l.Items, *u)
+		return l, nil
+	}
+
+	objects, _, err := unstructured.NestedSlice(u.Object, "objects")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read template objects: %w", err)
+	}
+
+	for _, o := range objects {
+		if m, ok := o.(map[string]interface{}); ok {
+			l.Items = append(l.Items, unstructured.Unstructured{Object: m})
+		}
+	}
+
+	return l, nil
+}
+
+// table returns a row for each item of l. The wide columns have priority 1.
+func (p *printed) table(l *unstructured.UnstructuredList) *metav1.Table {
+	table := &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Namespace", Type: "string"},
+			{Name: "Name", Type: "string"},
+			{Name: "DeploymentConfig", Type: "string"},
+			{Name: "Warnings", Type: "string"},
+			{Name: "Action", Type: "string"},
+			{Name: "Replicas", Type: "string", Priority: 1},
+			{Name: "Strategy", Type: "string", Priority: 1},
+			{Name: "Images", Type: "string", Priority: 1},
+		},
+	}
+
+	for i := range l.Items {
+		u := &l.Items[i]
+		dc, warnings := none, none
+
+		if n, ok := p.warnings[u.GetName()]; ok && u.GetKind() == "Deployment" {
+			dc, warnings = u.GetName(), strconv.Itoa(n)
+		}
+
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells: []interface{}{
+				orNone(u.GetNamespace()),
+				resourceName(u),
+				dc,
+				warnings,
+				p.action,
+				field(u, "spec", "replicas"),
+				field(u, "spec", "strategy", "type"),
+				images(u),
+			},
+		})
+	}
+
+	return table
+}
+
+// resourceName returns the name of u as kubectl writes it, as kind.group/name.
+func resourceName(u *unstructured.Unstructured) string {
+	gvk := u.GroupVersionKind()
+	kind := strings.ToLower(gvk.Kind)
+
+	if gvk.Group != "" {
+		kind += "." + gvk.Group
+	}
+
+	return kind + "/" + u.GetName()
+}
+
+func field(u *unstructured.Unstructured, fields ...string) string {
+	v, ok, err := unstructured.NestedFieldNoCopy(u.Object, fields...)
+	if err != nil || !ok {
+		return none
+	}
+
+	return fmt.Sprint(v)
+}
+
+func images(u *unstructured.Unstructured) string {
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+
+	var result []string
+
+	for _, c := range containers {
+		if m, ok := c.(map[string]interface{}); ok {
+			if image, ok := m["image"].(string); ok {
+				result = append(result, image)
+			}
+		}
+	}
+
+	return orNone(strings.Join(result, ","))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return none
+	}
+
+	return s
+}
+
+type column struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+type columns []column
+
+// parseColumns parses a kubectl custom-columns spec, as HEADER:path,...
+func parseColumns(spec string) (columns, error) {
+	var result columns
+
+	for _, c := range strings.Split(spec, ",") {
+		header, path, ok := strings.Cut(c, ":")
+		if !ok || header == "" || path == "" {
+			return nil, fmt.Errorf("invalid custom-columns %s: expected HEADER:path", c)
+		}
+
+		j := jsonpath.New(header).AllowMissingKeys(true)
+
+		if err := j.Parse(relaxedPath(path)); err != nil {
+			return nil, fmt.Errorf("unable to parse custom-columns path %s: %w", path, err)
+		}
+
+		result = append(result, column{header: header, path: j})
+	}
+
+	return result, nil
+}
+
+// relaxedPath adds the braces and leading dot a custom-columns path may leave
+// out, as kubectl does.
+func relaxedPath(path string) string {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}")
+
+	if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "$") {
+		path = "." + path
+	}
+
+	return "{" + path + "}"
+}
+
+func (c columns) table(l *unstructured.UnstructuredList) (*metav1.Table, error) {
+	table := &metav1.Table{}
+
+	for _, col := range c {
+		table.ColumnDefinitions = append(table.ColumnDefinitions, metav1.TableColumnDefinition{Name: col.header, Type: "string"})
+	}
+
+	for i := range l.Items {
+		row := metav1.TableRow{}
+
+		for _, col := range c {
+			results, err := col.path.FindResults(l.Items[i].Object)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find %s: %w", col.header, err)
+			}
+
+			var values []string
+
+			for _, r := range results {
+				for _, v := range r {
+					values = append(values, fmt.Sprint(v.Interface()))
+				}
+			}
+
+			row.Cells = append(row.Cells, orNone(strings.Join(values, ",")))
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table, nil
+}
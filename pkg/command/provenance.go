@@ -0,0 +1,84 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+)
+
+type ReportFormat string
+
+const (
+	TableReportFormat ReportFormat = "table"
+	JSONReportFormat  ReportFormat = "json"
+	YAMLReportFormat  ReportFormat = "yaml"
+	SARIFReportFormat ReportFormat = "sarif"
+	JUnitReportFormat ReportFormat = "junit"
+	CSVReportFormat   ReportFormat = "csv"
+	HTMLReportFormat  ReportFormat = "html"
+)
+
+// printProvenance writes the provenance report to stderr so it does not mix
+// with the converted object.
+func printProvenance(p convert.Provenance) error {
+	switch Options.Provenance {
+	case TableReportFormat:
+		var b bytes.Buffer
+
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PATH\tSOURCE\tTRANSFORM")
+
+		for _, f := range p {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", orDash(f.Path), orDash(f.Source), f.Transform)
+		}
+
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("unable to render provenance: %w", err)
+		}
+
+		writer.WriteErr(0, "%s", b.String())
+	case JSONReportFormat:
+		o, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal provenance: %w", err)
+		}
+
+		writer.WriteErr(0, "%s", o)
+	}
+
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,149 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/report"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DoScan runs the feature, custom, rollout and preflight checks on every
+// DeploymentConfig in the selected namespaces and writes a readiness report.
+func DoScan() error {
+	if err := initClient(); err != nil {
+		return err
+	}
+
+	namespaces := Options.ScanNamespaces
+
+	switch {
+	case Options.ScanAllNamespaces:
+		namespaces = []string{metav1.NamespaceAll}
+	case len(namespaces) == 0:
+		namespaces = []string{Options.LiveNamespace}
+	}
+
+	var entries []*report.ScanEntry
+
+	for _, ns := range namespaces {
+		dcs, err := source.ListDCs(ns)
+		if err != nil {
+			return err
+		}
+
+		for _, dc := range dcs {
+			writer.WriteErr(1, "scanning deploymentconfig %s/%s", dc.Namespace, dc.Name)
+
+			warnings, _, err := evaluateWarnings(dc)
+			if err != nil {
+				return fmt.Errorf("unable to scan %s/%s: %w", dc.Namespace, dc.Name, err)
+			}
+
+			// warn warnings are ignored so they need review instead of
+			// blocking; only blocking and denied warnings block.
+			policy := warningPolicy()
+			policy.IgnoreAll = true
+			policy.Apply(dc, warnings)
+
+			e := report.NewScanEntry(convert.ObjectReference{
+				Kind:      "DeploymentConfig",
+				Namespace: dc.Namespace,
+				Name:      dc.Name,
+			}, warnings)
+			e.Hooks = countHooks(dc)
+			e.Triggers = len(dc.Spec.Triggers)
+			e.References = countReferences(dc)
+
+			entries = append(entries, e)
+		}
+	}
+
+	var (
+		o   []byte
+		err error
+	)
+
+	s := report.NewScan(entries)
+
+	switch Options.ScanFormat {
+	case TableReportFormat:
+		o, err = report.ScanTable(s)
+	case CSVReportFormat:
+		o, err = report.ScanCSV(s)
+	case JSONReportFormat:
+		o, err = report.ScanJSON(s)
+	case HTMLReportFormat:
+		o, err = report.ScanHTML(s)
+	default:
+		return fmt.Errorf("unknown scan format: %s (use table, csv, json or html)", Options.ScanFormat)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteFile(outputFilename(), o)
+}
+
+func countHooks(dc *ocappsv1.DeploymentConfig) int {
+	var hooks []*ocappsv1.LifecycleHook
+
+	if p := dc.Spec.Strategy.RollingParams; p != nil {
+		hooks = append(hooks, p.Pre, p.Post)
+	}
+
+	if p := dc.Spec.Strategy.RecreateParams; p != nil {
+		hooks = append(hooks, p.Pre, p.Mid, p.Post)
+	}
+
+	count := 0
+
+	for _, h := range hooks {
+		if h != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// countReferences counts the distinct objects the pod template of dc
+// references.
+func countReferences(dc *ocappsv1.DeploymentConfig) int {
+	if dc.Spec.Template == nil {
+		return 0
+	}
+
+	seen := map[string]bool{}
+
+	for _, ref := range convert.References("spec.template.spec", &dc.Spec.Template.Spec) {
+		seen[ref.Kind+"/"+ref.Name] = true
+	}
+
+	return len(seen)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/report"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	templatev1 "github.com/openshift/api/template/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// convertTemplate writes a copy of t, or a Helm chart of it, with each
+// DeploymentConfig replaced by its conversion. Parameter references in the
+// DeploymentConfigs are kept as they are, including in numeric fields. Services
+// and HorizontalPodAutoscalers using the DeploymentConfigs are pointed at the
+// Deployments, and other objects are left alone.
+func convertTemplate(t *templatev1.Template) error {
+	out := t.DeepCopy()
+	out.Objects = nil
+
+	var (
+		results   []*report.Result
+		blocking  []*convert.Warning
+		converted = newPrinted(ConvertedAction)
+		dcs       []*ocappsv1.DeploymentConfig
+		related   = map[int]*unstructured.Unstructured{}
+	)
+
+	for _, raw := range t.Objects {
+		dc, p, err := convert.TemplateDC(raw)
+		if err != nil {
+			return err
+		}
+
+		if dc == nil {
+			u := &unstructured.Unstructured{}
+
+			if err := json.Unmarshal(raw.Raw, &u.Object); err != nil {
+				return fmt.Errorf("unable to parse template object: %w", err)
+			}
+
+			related[len(out.Objects)] = u
+			out.Objects = append(out.Objects, raw)
+
+			continue
+		}
+
+		dcs = append(dcs, dc)
+
+		w, b, err := evaluateWarnings(dc)
+		if err != nil {
+			return err
+		}
+
+		results = append(results, warningsResult(dc, w))
+		converted.warnings[dc.Name] = len(w)
+		blocking = append(blocking, b...)
+
+		obj, err := convertDC(dc)
+		if err != nil {
+			return err
+		}
+
+		jobs, err := hookJobs(dc)
+		if err != nil {
+			return err
+		}
+
+		items := []interface{}{obj}
+
+		if l, ok := obj.(*corev1.List); ok {
+			items = nil
+
+			for i := range l.Items {
+				items = append(items, &l.Items[i])
+			}
+		}
+
+		for _, j := range jobs {
+			items = append(items, j)
+		}
+
+		restore := p.Restore
+		if Options.HelmChart != "" {
+			restore = p.RestoreTyped
+		}
+
+		for _, item := range items {
+			restored, err := restore(item)
+			if err != nil {
+				return err
+			}
+
+			out.Objects = append(out.Objects, restored)
+		}
+	}
+
+	for i, u := range related {
+		if err := rewriteRelated([]*unstructured.Unstructured{u}, dcs); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(u.Object)
+		if err != nil {
+			return fmt.Errorf("unable to marshal template object: %w", err)
+		}
+
+		out.Objects[i] = runtime.RawExtension{Raw: data}
+	}
+
+	if Options.WarningsFormat != "" {
+		return writeWarnings(results...)
+	}
+
+	var warnings []*convert.Warning
+
+	for _, r := range results {
+		warnings = append(warnings, r.Warnings...)
+	}
+
+	if err := checkWarnings(warnings, blocking); err != nil {
+		return err
+	}
+
+	if Options.HelmChart != "" {
+		var objs []interface{}
+
+		for _, o := range out.Objects {
+			objs = append(objs, o)
+		}
+
+		return writeChart(t, objs)
+	}
+
+	o, err := output(out, converted)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteFile(Options.OutputFilename, o)
+}
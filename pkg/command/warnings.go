@@ -0,0 +1,165 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/report"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	yaml "sigs.k8s.io/yaml"
+)
+
+// evaluateWarnings runs the built-in and custom checks on dc, and the rollout
+// and preflight checks when converting live, then applies the warning policy. It
+// returns all warnings and the ones that block the conversion.
+func evaluateWarnings(dc *ocappsv1.DeploymentConfig) ([]*convert.Warning, []*convert.Warning, error) {
+	return evaluate(dc, Options.inputType == LiveIOType)
+}
+
+// evaluate runs the checks of evaluateWarnings, leaving out the cluster checks
+// unless live is set.
+func evaluate(dc *ocappsv1.DeploymentConfig, live bool) ([]*convert.Warning, []*convert.Warning, error) {
+	warnings := withoutHelmHooks(convert.CheckFeatures(dc))
+
+	deploy, _, err := toDeploy(dc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	custom, err := Options.customChecks.Evaluate(dc, deploy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to run custom checks: %w", err)
+	}
+
+	warnings = append(warnings, custom...)
+
+	if live {
+		warnings = append(warnings, source.CheckRollout(dc)...)
+		var deps []*unstructured.Unstructured
+
+		if Options.Dependencies {
+			deps, err = dependencies(dc)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		warnings = append(warnings, target.Preflight(dc, deploy, deps)...)
+	}
+
+	return warnings, warningPolicy().Apply(dc, warnings), nil
+}
+
+// warningPolicy returns the WarningPolicy set by the warning options.
+func warningPolicy() *convert.WarningPolicy {
+	return &convert.WarningPolicy{
+		IgnoreAll: Options.IgnoreWarnings,
+		Allow:     Options.AllowWarnings,
+		Deny:      Options.DenyWarnings,
+	}
+}
+
+// checkWarnings prints w and fails if any warnings block.
+func checkWarnings(w []*convert.Warning, blocking []*convert.Warning) error {
+	if len(w) != len(blocking) {
+		writer.WriteErr(2, "ignoring %d allowed warnings", len(w)-len(blocking))
+	}
+
+	for _, warning := range w {
+		if warning.Allowed {
+			warning.Print(2)
+		} else {
+			warning.Print(0)
+		}
+	}
+
+	if blocking != nil {
+		return fmt.Errorf("blocked by %d warnings: use --allow-warning, or --ignore-warnings for warn severities, to continue", len(blocking))
+	}
+
+	return nil
+}
+
+// writeWarnings writes the warnings raised for each DeploymentConfig on their
+// own in the selected WarningsFormat instead of the converted objects.
+func writeWarnings(results ...*report.Result) error {
+	var (
+		o   []byte
+		err error
+	)
+
+	w := []*convert.Warning{}
+
+	for _, r := range results {
+		w = append(w, r.Warnings...)
+	}
+
+	switch Options.WarningsFormat {
+	case JSONReportFormat:
+		o, err = json.MarshalIndent(w, "", "  ")
+	case YAMLReportFormat:
+		o, err = yaml.Marshal(w)
+	case SARIFReportFormat:
+		o, err = report.SARIF(results)
+	case JUnitReportFormat:
+		o, err = report.JUnit(results)
+	default:
+		return fmt.Errorf("unknown warnings format: %s (use json, yaml, sarif or junit)", Options.WarningsFormat)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to marshal warnings: %w", err)
+	}
+
+	return writer.WriteFile(outputFilename(), o)
+}
+
+func warningsResult(dc *ocappsv1.DeploymentConfig, w []*convert.Warning) *report.Result {
+	r := &report.Result{
+		Object: convert.ObjectReference{
+			Kind:      "DeploymentConfig",
+			Namespace: dc.Namespace,
+			Name:      dc.Name,
+		},
+		Warnings: w,
+	}
+
+	if Options.inputType == FileIOType {
+		r.File = Options.Filename
+	}
+
+	return r
+}
+
+func outputFilename() string {
+	if Options.OutputFilename == "" {
+		return "-"
+	}
+
+	return Options.OutputFilename
+}
@@ -23,48 +23,86 @@ THE SOFTWARE.
 package convert
 
 import (
+	"fmt"
+
 	"github.com/csfreak/dc2deploy/pkg/writer"
 	ocappsv1 "github.com/openshift/api/apps/v1"
 )
 
+type Severity string
+
+const (
+	InfoSeverity     Severity = "info"
+	WarnSeverity     Severity = "warn"
+	BlockingSeverity Severity = "blocking"
+)
+
+type ObjectReference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// Warning describes a DeploymentConfig feature that does not survive
+// conversion. The package level Warnings are templates; CheckFeatures returns
+// copies with the concrete Path, Value and Object filled in.
 type Warning struct {
-	Name        string
-	Path        string
-	Description string
+	Code        string          `json:"code"`
+	Severity    Severity        `json:"severity"`
+	Name        string          `json:"name"`
+	Path        string          `json:"path"`
+	Value       string          `json:"value,omitempty"`
+	Description string          `json:"description"`
+	Object      ObjectReference `json:"object"`
+	Allowed     bool            `json:"allowed"`
 }
 
 var (
 	OwnerReferenceWarning = &Warning{
+		Code:        "OwnerReference",
+		Severity:    WarnSeverity,
 		Name:        "OwnerReference",
 		Path:        "metadata.ownerReferences",
 		Description: "DeploymentConfigs with OwnerReferences set are managed by another process.",
 	}
 	UnsupportedFeatureTestWarning = &Warning{
+		Code:        "UnsupportedTest",
+		Severity:    WarnSeverity,
 		Name:        "UnsupportedFeature - Test",
 		Path:        "spec.test",
 		Description: "The test feature is not supported on Deployments.",
 	}
 	UnsupportedFeatureCustomWarning = &Warning{
+		Code:        "UnsupportedCustomStrategy",
+		Severity:    WarnSeverity,
 		Name:        "UnsupportedFeature - Custom Strategy",
 		Path:        "spec.strategy.type",
 		Description: "The custom deployment strategy is not supported on Deployments.",
 	}
 	UnsupportedFeatureHooksWarning = &Warning{
+		Code:        "UnsupportedHooks",
+		Severity:    WarnSeverity,
 		Name:        "UnsupportedFeature - Lifecycle Hooks",
 		Path:        "spec.strategy.*Params.['pre','mid','post']",
 		Description: "The LifeCycleHooks are not supported on Deployments.",
 	}
 	UnsupportedFeatureRollingIntervalSecondsWarning = &Warning{
+		Code:        "UnsupportedIntervalSeconds",
+		Severity:    InfoSeverity,
 		Name:        "UnsupportedFeature - Rolling IntervalSeconds ",
-		Path:        "spec.strategy.RollingParams.IntervalSeconds",
+		Path:        "spec.strategy.rollingParams.intervalSeconds",
 		Description: "The IntervalSeconds setting is not supported on Deployments.",
 	}
 	UnsupportedFeatureRollingUpdatePeriodSecondsWarning = &Warning{
+		Code:        "UnsupportedUpdatePeriodSeconds",
+		Severity:    InfoSeverity,
 		Name:        "UnsupportedFeature - Rolling UpdatePeriodSeconds ",
-		Path:        "spec.strategy.RollingParams.UpdatePeriodSeconds",
+		Path:        "spec.strategy.rollingParams.updatePeriodSeconds",
 		Description: "The UpdatePeriodSeconds setting is not supported on Deployments.",
 	}
 	ChangedLabelWarning = &Warning{
+		Code:        "ChangedLabel",
+		Severity:    InfoSeverity,
 		Name:        "Selector Labels Changed",
 		Path:        "spec.selector",
 		Description: "The Selector label 'deploymentconfig' will be changed to 'deployment'.",
@@ -74,43 +112,91 @@ var (
 func CheckFeatures(orig *ocappsv1.DeploymentConfig) []*Warning {
 	var result []*Warning
 
-	if len(orig.OwnerReferences) != 0 {
-		result = append(result, OwnerReferenceWarning)
+	obj := ObjectReference{
+		Kind:      "DeploymentConfig",
+		Namespace: orig.Namespace,
+		Name:      orig.Name,
+	}
+
+	for i, ref := range orig.OwnerReferences {
+		result = append(result, OwnerReferenceWarning.At(obj, fmt.Sprintf("metadata.ownerReferences[%d]", i), ref.Kind+"/"+ref.Name))
 	}
 
 	if orig.Spec.Test {
-		result = append(result, UnsupportedFeatureTestWarning)
+		result = append(result, UnsupportedFeatureTestWarning.At(obj, "spec.test", "true"))
 	}
 
 	switch {
 	case orig.Spec.Strategy.Type == ocappsv1.DeploymentStrategyTypeCustom:
-		result = append(result, UnsupportedFeatureCustomWarning)
+		result = append(result, UnsupportedFeatureCustomWarning.At(obj, "spec.strategy.type", string(orig.Spec.Strategy.Type)))
 	case orig.Spec.Strategy.RollingParams != nil:
-		if orig.Spec.Strategy.RollingParams.Pre != nil || orig.Spec.Strategy.RollingParams.Post != nil {
-			result = append(result, UnsupportedFeatureHooksWarning)
-		}
+		p := orig.Spec.Strategy.RollingParams
+
+		result = append(result, hookWarnings(obj, "spec.strategy.rollingParams.pre", p.Pre)...)
+		result = append(result, hookWarnings(obj, "spec.strategy.rollingParams.post", p.Post)...)
 
-		if orig.Spec.Strategy.RollingParams.IntervalSeconds != nil {
-			result = append(result, UnsupportedFeatureRollingIntervalSecondsWarning)
+		if p.IntervalSeconds != nil {
+			result = append(result, UnsupportedFeatureRollingIntervalSecondsWarning.At(obj, "spec.strategy.rollingParams.intervalSeconds", fmt.Sprint(*p.IntervalSeconds)))
 		}
 
-		if orig.Spec.Strategy.RollingParams.UpdatePeriodSeconds != nil {
-			result = append(result, UnsupportedFeatureRollingUpdatePeriodSecondsWarning)
+		if p.UpdatePeriodSeconds != nil {
+			result = append(result, UnsupportedFeatureRollingUpdatePeriodSecondsWarning.At(obj, "spec.strategy.rollingParams.updatePeriodSeconds", fmt.Sprint(*p.UpdatePeriodSeconds)))
 		}
 
 	case orig.Spec.Strategy.RecreateParams != nil:
-		if orig.Spec.Strategy.RecreateParams.Pre != nil || orig.Spec.Strategy.RecreateParams.Mid != nil || orig.Spec.Strategy.RecreateParams.Post != nil {
-			result = append(result, UnsupportedFeatureHooksWarning)
-		}
+		p := orig.Spec.Strategy.RecreateParams
+
+		result = append(result, hookWarnings(obj, "spec.strategy.recreateParams.pre", p.Pre)...)
+		result = append(result, hookWarnings(obj, "spec.strategy.recreateParams.mid", p.Mid)...)
+		result = append(result, hookWarnings(obj, "spec.strategy.recreateParams.post", p.Post)...)
+	}
+
+	if v, ok := orig.Spec.Selector[DeploymentConfigPodLabel]; ok {
+		result = append(result, ChangedLabelWarning.At(obj, FieldPath("spec.selector", DeploymentConfigPodLabel), v))
+	}
+
+	return result
+}
+
+// hookWarnings returns a warning for each action of a lifecycle hook, naming
+// the container or image tags it would have run.
+func hookWarnings(obj ObjectReference, path string, hook *ocappsv1.LifecycleHook) []*Warning {
+	if hook == nil {
+		return nil
+	}
+
+	var result []*Warning
+
+	if hook.ExecNewPod != nil {
+		result = append(result, UnsupportedFeatureHooksWarning.At(obj, path+".execNewPod.containerName", hook.ExecNewPod.ContainerName))
 	}
 
-	if _, ok := orig.Spec.Selector[DeploymentConfigPodLabel]; ok {
-		result = append(result, ChangedLabelWarning)
+	for i, t := range hook.TagImages {
+		result = append(result, UnsupportedFeatureHooksWarning.At(obj, fmt.Sprintf("%s.tagImages[%d].containerName", path, i), t.ContainerName))
+	}
+
+	if result == nil {
+		result = append(result, UnsupportedFeatureHooksWarning.At(obj, path, ""))
 	}
 
 	return result
 }
 
+// At returns a copy of w for the field at path of obj.
+func (w *Warning) At(obj ObjectReference, path, value string) *Warning {
+	c := *w
+	c.Object = obj
+	c.Path = path
+	c.Value = value
+
+	return &c
+}
+
 func (w *Warning) Print(level uint8) {
-	writer.WriteErr(level, "Conversion Warning: %s\n%s: path - %s", w.Name, w.Description, w.Path)
+	if w.Value != "" {
+		writer.WriteErr(level, "Conversion Warning: %s [%s/%s]\n%s: path - %s (%s)", w.Name, w.Code, w.Severity, w.Description, w.Path, w.Value)
+		return
+	}
+
+	writer.WriteErr(level, "Conversion Warning: %s [%s/%s]\n%s: path - %s", w.Name, w.Code, w.Severity, w.Description, w.Path)
 }
@@ -27,6 +27,16 @@ const (
 	GeneratedByAnnotationKey = "openshift.io/generated-by"
 	DeploymentConfigPodLabel = "deploymentconfig"
 	DeploymentPodLabel       = "deployment"
+
+	IgnoreWarningsAnnotationKey = "dc2deploy/ignore-warnings"
+
+	CutoverStateAnnotationKey = "dc2deploy/cutover-state"
+	CutoverFromAnnotationKey  = "dc2deploy/cutover-from"
+
+	StashAnnotationKey          = "dc2deploy/stash"
+	StashConfigMapAnnotationKey = "dc2deploy/stash-configmap"
+	StashConfigMapKey           = "stash.json"
+	StashConfigMapSuffix        = "-dc2deploy-stash"
 )
 
 var (
@@ -25,15 +25,28 @@ package convert
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	ocappsv1 "github.com/openshift/api/apps/v1"
 	"github.com/openshift/library-go/pkg/image/trigger"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	yaml "sigs.k8s.io/yaml"
 )
 
 func ToDeploy(orig *ocappsv1.DeploymentConfig) (*appsv1.Deployment, error) {
+	deploy, _, err := ToDeployWithProvenance(orig)
+
+	return deploy, err
+}
+
+// ToDeployWithProvenance converts orig and reports, for each output field, the
+// DeploymentConfig field it came from and how it was transformed.
+func ToDeployWithProvenance(orig *ocappsv1.DeploymentConfig) (*appsv1.Deployment, Provenance, error) {
+	var p Provenance
+
 	dc := orig.DeepCopy()
 	deploy := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
@@ -51,6 +64,24 @@ func ToDeploy(orig *ocappsv1.DeploymentConfig) (*appsv1.Deployment, error) {
 		Status: appsv1.DeploymentStatus{},
 	}
 
+	p.add("apiVersion", "", DefaultedTransform)
+	p.add("kind", "", DefaultedTransform)
+	p.add("metadata.name", "metadata.name", CopiedTransform)
+
+	if dc.GenerateName != "" {
+		p.add("metadata.generateName", "metadata.generateName", CopiedTransform)
+	}
+
+	if dc.Namespace != "" {
+		p.add("metadata.namespace", "metadata.namespace", CopiedTransform)
+	}
+
+	for _, k := range sortedKeys(dc.Labels) {
+		p.add(FieldPath("metadata.labels", k), FieldPath("metadata.labels", k), CopiedTransform)
+	}
+
+	p.addAnnotations("metadata.annotations", "metadata.annotations", dc.Annotations)
+
 	dc.Spec.Template.DeepCopyInto(&deploy.Spec.Template)
 	deploy.Spec.Template.Annotations = cleanAnnotations(dc.Spec.Template.Annotations)
 	deploy.Spec.Template.Labels = cleanLabels(dc.Spec.Template.Labels)
@@ -62,6 +93,25 @@ func ToDeploy(orig *ocappsv1.DeploymentConfig) (*appsv1.Deployment, error) {
 	deploy.Spec.RevisionHistoryLimit = dc.Spec.RevisionHistoryLimit
 	deploy.Spec.MinReadySeconds = dc.Spec.MinReadySeconds
 
+	p.add("spec.template.spec", "spec.template.spec", CopiedTransform)
+	p.addAnnotations("spec.template.metadata.annotations", "spec.template.metadata.annotations", dc.Spec.Template.Annotations)
+	p.addLabels("spec.template.metadata.labels", "spec.template.metadata.labels", dc.Spec.Template.Labels)
+	p.addLabels("spec.selector.matchLabels", "spec.selector", dc.Spec.Selector)
+
+	if dc.Spec.Paused {
+		p.add("spec.paused", "spec.paused", CopiedTransform)
+	}
+
+	p.add("spec.replicas", "spec.replicas", CopiedTransform)
+
+	if dc.Spec.RevisionHistoryLimit != nil {
+		p.add("spec.revisionHistoryLimit", "spec.revisionHistoryLimit", CopiedTransform)
+	}
+
+	if dc.Spec.MinReadySeconds != 0 {
+		p.add("spec.minReadySeconds", "spec.minReadySeconds", CopiedTransform)
+	}
+
 	if dc.Spec.Strategy.Type == ocappsv1.DeploymentStrategyTypeRolling {
 		r := &appsv1.RollingUpdateDeployment{}
 
@@ -69,12 +119,26 @@ func ToDeploy(orig *ocappsv1.DeploymentConfig) (*appsv1.Deployment, error) {
 			r.MaxUnavailable = dc.Spec.Strategy.RollingParams.MaxUnavailable
 			r.MaxSurge = dc.Spec.Strategy.RollingParams.MaxSurge
 
+			if r.MaxUnavailable != nil {
+				p.add("spec.strategy.rollingUpdate.maxUnavailable", "spec.strategy.rollingParams.maxUnavailable", RenamedTransform)
+			}
+
+			if r.MaxSurge != nil {
+				p.add("spec.strategy.rollingUpdate.maxSurge", "spec.strategy.rollingParams.maxSurge", RenamedTransform)
+			}
+
 			if orig.Spec.Strategy.RollingParams.TimeoutSeconds != nil {
 				timeout32 := int32(*orig.Spec.Strategy.RollingParams.TimeoutSeconds)
 				deploy.Spec.ProgressDeadlineSeconds = &timeout32
+
+				p.add("spec.progressDeadlineSeconds", "spec.strategy.rollingParams.timeoutSeconds", DerivedTransform)
 			}
+		} else {
+			p.add("spec.strategy.rollingUpdate", "", DefaultedTransform)
 		}
 
+		p.add("spec.strategy.type", "spec.strategy.type", DerivedTransform)
+
 		deploy.Spec.Strategy = appsv1.DeploymentStrategy{
 			Type:          appsv1.RollingUpdateDeploymentStrategyType,
 			RollingUpdate: r,
@@ -87,13 +151,21 @@ func ToDeploy(orig *ocappsv1.DeploymentConfig) (*appsv1.Deployment, error) {
 		if orig.Spec.Strategy.RecreateParams != nil && orig.Spec.Strategy.RecreateParams.TimeoutSeconds != nil {
 			timeout32 := int32(*orig.Spec.Strategy.RecreateParams.TimeoutSeconds)
 			deploy.Spec.ProgressDeadlineSeconds = &timeout32
+
+			p.add("spec.progressDeadlineSeconds", "spec.strategy.recreateParams.timeoutSeconds", DerivedTransform)
 		}
+
+		p.add("spec.strategy.type", "spec.strategy.type", DerivedTransform)
 	}
 
+	p.addDropped(dc)
+
 	var triggers []trigger.ObjectFieldTrigger
 
-	for _, dctrigger := range dc.Spec.Triggers {
+	for i, dctrigger := range dc.Spec.Triggers {
 		if dctrigger.Type == ocappsv1.DeploymentTriggerOnImageChange {
+			p.add(FieldPath("metadata.annotations", trigger.TriggerAnnotationKey), fmt.Sprintf("spec.triggers[%d]", i), DerivedTransform)
+
 			for _, containername := range dctrigger.ImageChangeParams.ContainerNames {
 				triggers = append(triggers, trigger.ObjectFieldTrigger{
 					From: trigger.ObjectReference{
@@ -111,16 +183,16 @@ func ToDeploy(orig *ocappsv1.DeploymentConfig) (*appsv1.Deployment, error) {
 	if triggers != nil {
 		triggersjson, err := json.Marshal(triggers)
 		if err != nil {
-			return nil, fmt.Errorf("unable to marshal triggers: %w", err)
+			return nil, nil, fmt.Errorf("unable to marshal triggers: %w", err)
 		}
 
 		deploy.Annotations[trigger.TriggerAnnotationKey] = string(triggersjson)
 	}
 
-	return deploy, nil
+	return deploy, p, nil
 }
 
-func ToOuput(d *appsv1.Deployment, filetype string) ([]byte, error) {
+func ToOuput(d runtime.Object, filetype string) ([]byte, error) {
 	switch filetype {
 	case "yaml":
 		return yaml.Marshal(d)
@@ -131,6 +203,26 @@ func ToOuput(d *appsv1.Deployment, filetype string) ([]byte, error) {
 	}
 }
 
+func ToList(objs ...runtime.Object) (*corev1.List, error) {
+	l := &corev1.List{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "List",
+		},
+	}
+
+	for _, o := range objs {
+		raw, err := json.Marshal(o)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal list item: %w", err)
+		}
+
+		l.Items = append(l.Items, runtime.RawExtension{Raw: raw})
+	}
+
+	return l, nil
+}
+
 func cleanAnnotations(a map[string]string) map[string]string {
 	var o = make(map[string]string)
 
@@ -161,3 +253,15 @@ func cleanLabels(l map[string]string) map[string]string {
 
 	return o
 }
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
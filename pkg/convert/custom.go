@@ -0,0 +1,195 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/cel-go/cel"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// CustomCheck is a user defined check. Expression is a CEL expression over
+// the DeploymentConfig as `dc` and the converted Deployment as `deploy`; the
+// check raises a Warning when it evaluates to true.
+type CustomCheck struct {
+	Code       string   `json:"code"`
+	Severity   Severity `json:"severity,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Path       string   `json:"path,omitempty"`
+	Message    string   `json:"message"`
+	Expression string   `json:"expression"`
+
+	program     cel.Program
+	warningTmpl *Warning
+}
+
+// CustomCheckErrorWarning is raised in place of a custom check that cannot be
+// evaluated on an object, so one object does not stop the others from being
+// checked. It blocks like the check when the check is blocking.
+var CustomCheckErrorWarning = &Warning{
+	Code:        "CustomCheckError",
+	Severity:    WarnSeverity,
+	Name:        "Custom Check Failed",
+	Description: "The custom check could not be evaluated.",
+}
+
+type CustomChecks struct {
+	Checks []*CustomCheck `json:"checks"`
+}
+
+// LoadChecks reads and compiles the custom checks in the file at path.
+func LoadChecks(path string) (*CustomChecks, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file: %w", err)
+	}
+
+	checks := &CustomChecks{}
+
+	err = yaml.Unmarshal(data, checks)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse checks: %w", err)
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable("dc", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("deploy", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cel environment: %w", err)
+	}
+
+	for _, c := range checks.Checks {
+		if err := c.compile(env); err != nil {
+			return nil, fmt.Errorf("invalid check %q: %w", c.Code, err)
+		}
+	}
+
+	return checks, nil
+}
+
+func (c *CustomCheck) compile(env *cel.Env) error {
+	if c.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+
+	switch c.Severity {
+	case "":
+		c.Severity = WarnSeverity
+	case InfoSeverity, WarnSeverity, BlockingSeverity:
+	default:
+		return fmt.Errorf("unknown severity: %s (use info, warn or blocking)", c.Severity)
+	}
+
+	if c.Name == "" {
+		c.Name = c.Code
+	}
+
+	ast, iss := env.Compile(c.Expression)
+	if iss.Err() != nil {
+		return fmt.Errorf("unable to compile expression: %w", iss.Err())
+	}
+
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return fmt.Errorf("expression must return bool, not %s", t)
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return fmt.Errorf("unable to build program: %w", err)
+	}
+
+	c.program = prg
+	c.warningTmpl = &Warning{
+		Code:        c.Code,
+		Severity:    c.Severity,
+		Name:        c.Name,
+		Path:        c.Path,
+		Description: c.Message,
+	}
+
+	return nil
+}
+
+// Evaluate runs the checks against dc and deploy, returning a Warning for
+// each check that matches and a CustomCheckErrorWarning for each check that
+// fails to evaluate.
+func (c *CustomChecks) Evaluate(dc *ocappsv1.DeploymentConfig, deploy *appsv1.Deployment) ([]*Warning, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	dcmap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(dc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert deploymentconfig: %w", err)
+	}
+
+	deploymap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deploy)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert deployment: %w", err)
+	}
+
+	obj := ObjectReference{
+		Kind:      "DeploymentConfig",
+		Namespace: dc.Namespace,
+		Name:      dc.Name,
+	}
+
+	var result []*Warning
+
+	for _, check := range c.Checks {
+		out, _, err := check.program.Eval(map[string]interface{}{
+			"dc":     dcmap,
+			"deploy": deploymap,
+		})
+		if err != nil {
+			result = append(result, check.failed(obj, fmt.Sprintf("unable to evaluate: %s", err)))
+			continue
+		}
+
+		if matched, ok := out.Value().(bool); !ok {
+			result = append(result, check.failed(obj, fmt.Sprintf("returned %v, not bool", out.Value())))
+		} else if matched {
+			result = append(result, check.warningTmpl.At(obj, check.Path, ""))
+		}
+	}
+
+	return result, nil
+}
+
+// failed returns a CustomCheckErrorWarning for the check on obj.
+func (c *CustomCheck) failed(obj ObjectReference, reason string) *Warning {
+	w := CustomCheckErrorWarning.At(obj, c.Path, c.Code)
+	w.Description = fmt.Sprintf("The custom check %s could not be evaluated: %s.", c.Code, reason)
+
+	if c.Severity == BlockingSeverity {
+		w.Severity = BlockingSeverity
+	}
+
+	return w
+}
@@ -0,0 +1,352 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const podSpecPath = "spec.template.spec"
+
+// FieldDiff is a field of a DeploymentConfig aligned with the field of the
+// Deployment it became, and of the Deployment on the cluster when there is
+// one. Values are empty when the side does not have the field.
+type FieldDiff struct {
+	Source    string    `json:"source,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Transform Transform `json:"transform"`
+	Note      string    `json:"note,omitempty"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Live      *string   `json:"live,omitempty"`
+}
+
+// Diff aligns the fields of dc with the fields of deploy, its conversion with
+// provenance p, and of live, the existing Deployment, if it is not nil. Only
+// fields that change, or that differ from live, are returned. The pod
+// template is compared field by field, and fields only live sets, other than
+// the ones the API server populates, are returned last.
+func Diff(dc *ocappsv1.DeploymentConfig, deploy *appsv1.Deployment, p Provenance, live *appsv1.Deployment) ([]*FieldDiff, error) {
+	from, err := runtime.DefaultUnstructuredConverter.ToUnstructured(dc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert dc: %w", err)
+	}
+
+	to, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deploy)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert deployment: %w", err)
+	}
+
+	var current map[string]interface{}
+
+	if live != nil {
+		current, err = runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert live deployment: %w", err)
+		}
+	}
+
+	var (
+		result   []*FieldDiff
+		recorded = map[string]bool{}
+	)
+
+	for _, f := range p {
+		recorded[f.Path] = true
+
+		if f.Path == podSpecPath {
+			continue
+		}
+
+		d := &FieldDiff{
+			Source:    f.Source,
+			Path:      f.Path,
+			Transform: f.Transform,
+			Note:      note(f),
+			From:      lookupValue(from, f.Source),
+			To:        lookupValue(to, f.Path),
+		}
+
+		if current != nil {
+			v := lookupValue(current, f.Path)
+			d.Live = &v
+		}
+
+		if d.changed() {
+			result = append(result, d)
+		}
+	}
+
+	// The pod spec is copied as a whole, so compare it leaf by leaf.
+	fromLeaves := leaves(lookup(from, podSpecPath), podSpecPath)
+	toLeaves := leaves(lookup(to, podSpecPath), podSpecPath)
+
+	var currentLeaves, liveLeaves map[string]string
+	if current != nil {
+		currentLeaves = leaves(lookup(current, podSpecPath), podSpecPath)
+		// server defaults are left out, so only fields set on purpose count.
+		liveLeaves = map[string]string{}
+
+		m := runtime.DeepCopyJSON(current)
+		m["kind"] = "Deployment"
+
+		for path, v := range leaves(minimal(m), "") {
+			if path = strings.TrimPrefix(path, "."); path != "apiVersion" && path != "kind" {
+				liveLeaves[path] = v
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(fromLeaves)+len(toLeaves))
+
+	for path := range fromLeaves {
+		paths = append(paths, path)
+	}
+
+	for path := range toLeaves {
+		if _, ok := fromLeaves[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+
+	for path := range liveLeaves {
+		_, inFrom := fromLeaves[path]
+		_, inTo := toLeaves[path]
+
+		if strings.HasPrefix(path, podSpecPath+".") && !inFrom && !inTo {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if recorded[path] {
+			continue
+		}
+
+		d := &FieldDiff{
+			Source:    path,
+			Path:      path,
+			Transform: CopiedTransform,
+			From:      fromLeaves[path],
+			To:        toLeaves[path],
+		}
+
+		if _, ok := fromLeaves[path]; !ok {
+			if _, ok := toLeaves[path]; !ok {
+				d.Source, d.Transform, d.Note = "", "", "only on cluster"
+			}
+		}
+
+		if current != nil {
+			v := currentLeaves[path]
+			d.Live = &v
+		}
+
+		if d.changed() {
+			result = append(result, d)
+		}
+	}
+
+	var liveOnly []string
+
+	for path := range liveLeaves {
+		if !strings.HasPrefix(path, podSpecPath+".") && !covered(path, recorded) && lookup(to, path) == nil {
+			liveOnly = append(liveOnly, path)
+		}
+	}
+
+	sort.Strings(liveOnly)
+
+	for _, path := range liveOnly {
+		v := liveLeaves[path]
+
+		result = append(result, &FieldDiff{
+			Path: path,
+			Note: "only on cluster",
+			Live: &v,
+		})
+	}
+
+	return result, nil
+}
+
+// covered reports whether path is a recorded field or inside one.
+func covered(path string, recorded map[string]bool) bool {
+	for p := range recorded {
+		if p != "" && (path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// changed returns whether the field is moved, changed or differs from the live
+// Deployment.
+func (d *FieldDiff) changed() bool {
+	return d.Source != d.Path || d.From != d.To || d.Transform != CopiedTransform || (d.Live != nil && *d.Live != d.To)
+}
+
+// note highlights label renames, dropped annotations and the strategy mapping.
+func note(f *FieldProvenance) string {
+	switch {
+	case f.Transform == RenamedTransform && strings.Contains(strings.ToLower(f.Path), "labels"):
+		return "label renamed"
+	case f.Transform == DroppedTransform && strings.Contains(f.Source, "annotations"):
+		return "annotation dropped"
+	case strings.HasPrefix(f.Source, "spec.strategy") || strings.HasPrefix(f.Path, "spec.strategy"):
+		return "strategy mapped"
+	}
+
+	return ""
+}
+
+// lookupValue returns the value at path of obj as a string, with maps and
+// lists as JSON, or an empty string if obj does not have it.
+func lookupValue(obj map[string]interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	return format(lookup(obj, path))
+}
+
+func format(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+
+		return string(data)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// lookup returns the value at path, as written by FieldPath, of obj.
+func lookup(obj interface{}, path string) interface{} {
+	v := obj
+
+	for _, s := range splitPath(path) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			v = t[s]
+		case []interface{}:
+			i, err := strconv.Atoi(s)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil
+			}
+
+			v = t[i]
+		default:
+			return nil
+		}
+	}
+
+	return v
+}
+
+// splitPath splits path into its keys and list indexes.
+func splitPath(path string) []string {
+	var (
+		parts []string
+		b     strings.Builder
+	)
+
+	flush := func() {
+		if b.Len() != 0 {
+			parts = append(parts, b.String())
+			b.Reset()
+		}
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '.':
+			flush()
+		case strings.HasPrefix(path[i:], "['"):
+			flush()
+
+			end := strings.Index(path[i+2:], "']")
+			if end < 0 {
+				return append(parts, path[i+2:])
+			}
+
+			parts = append(parts, path[i+2:i+2+end])
+			i += end + 3
+		case c == '[':
+			flush()
+		case c == ']':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	flush()
+
+	return parts
+}
+
+// leaves returns the scalar values of v by their path under path.
+func leaves(v interface{}, path string) map[string]string {
+	result := map[string]string{}
+
+	var walk func(v interface{}, path string)
+
+	walk = func(v interface{}, path string) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, mv := range t {
+				walk(mv, FieldPath(path, k))
+			}
+		case []interface{}:
+			for i, lv := range t {
+				walk(lv, fmt.Sprintf("%s[%d]", path, i))
+			}
+		case nil:
+		default:
+			result[path] = format(t)
+		}
+	}
+
+	walk(v, path)
+
+	return result
+}
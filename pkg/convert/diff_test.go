@@ -0,0 +1,153 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiff(t *testing.T) {
+	dc := &ocappsv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Annotations: map[string]string{"openshift.io/generated-by": "x"},
+		},
+		Spec: ocappsv1.DeploymentConfigSpec{
+			Replicas: 2,
+			Selector: map[string]string{"app": "app"},
+			Template: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "quay.io/app:1"}},
+				},
+			},
+		},
+	}
+
+	replicas := int32(2)
+	progressDeadline := int32(600)
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "registry.example.com/app:1"}},
+				},
+			},
+		},
+	}
+
+	p := Provenance{
+		{Path: "metadata.name", Source: "metadata.name", Transform: CopiedTransform},
+		{Path: "spec.replicas", Source: "spec.replicas", Transform: CopiedTransform},
+		{Path: "spec.selector.matchLabels", Source: "spec.selector", Transform: RenamedTransform},
+		{Source: "metadata.annotations['openshift.io/generated-by']", Transform: DroppedTransform},
+		{Path: podSpecPath, Source: podSpecPath, Transform: CopiedTransform},
+	}
+
+	live := deploy.DeepCopy()
+	liveReplicas := int32(3)
+	live.Spec.Replicas = &liveReplicas
+
+	extended := live.DeepCopy()
+	extended.Spec.MinReadySeconds = 5
+	extended.Spec.ProgressDeadlineSeconds = &progressDeadline
+	extended.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "A", Value: "1"}}
+
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name string
+		live *appsv1.Deployment
+		want []*FieldDiff
+	}{
+		{
+			name: "conversion",
+			want: []*FieldDiff{
+				{Source: "spec.selector", Path: "spec.selector.matchLabels", Transform: RenamedTransform, Note: "label renamed", From: `{"app":"app"}`, To: `{"app":"app"}`},
+				{Source: "metadata.annotations['openshift.io/generated-by']", Transform: DroppedTransform, Note: "annotation dropped", From: "x"},
+				{Source: "spec.template.spec.containers[0].image", Path: "spec.template.spec.containers[0].image", Transform: CopiedTransform, From: "quay.io/app:1", To: "registry.example.com/app:1"},
+			},
+		},
+		{
+			name: "with live deployment",
+			live: live,
+			want: []*FieldDiff{
+				{Source: "spec.replicas", Path: "spec.replicas", Transform: CopiedTransform, From: "2", To: "2", Live: str("3")},
+				{Source: "spec.selector", Path: "spec.selector.matchLabels", Transform: RenamedTransform, Note: "label renamed", From: `{"app":"app"}`, To: `{"app":"app"}`, Live: str(`{"app":"app"}`)},
+				{Source: "metadata.annotations['openshift.io/generated-by']", Transform: DroppedTransform, Note: "annotation dropped", From: "x", Live: str("")},
+				{Source: "spec.template.spec.containers[0].image", Path: "spec.template.spec.containers[0].image", Transform: CopiedTransform, From: "quay.io/app:1", To: "registry.example.com/app:1", Live: str("registry.example.com/app:1")},
+			},
+		},
+		{
+			name: "with fields only live sets",
+			live: extended,
+			want: []*FieldDiff{
+				{Source: "spec.replicas", Path: "spec.replicas", Transform: CopiedTransform, From: "2", To: "2", Live: str("3")},
+				{Source: "spec.selector", Path: "spec.selector.matchLabels", Transform: RenamedTransform, Note: "label renamed", From: `{"app":"app"}`, To: `{"app":"app"}`, Live: str(`{"app":"app"}`)},
+				{Source: "metadata.annotations['openshift.io/generated-by']", Transform: DroppedTransform, Note: "annotation dropped", From: "x", Live: str("")},
+				{Path: "spec.template.spec.containers[0].env[0].name", Note: "only on cluster", Live: str("A")},
+				{Path: "spec.template.spec.containers[0].env[0].value", Note: "only on cluster", Live: str("1")},
+				{Source: "spec.template.spec.containers[0].image", Path: "spec.template.spec.containers[0].image", Transform: CopiedTransform, From: "quay.io/app:1", To: "registry.example.com/app:1", Live: str("registry.example.com/app:1")},
+				{Path: "spec.minReadySeconds", Note: "only on cluster", Live: str("5")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(dc, deploy, p, tt.live)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() =\n%s\nwant\n%s", diffString(got), diffString(tt.want))
+			}
+		})
+	}
+}
+
+func diffString(d []*FieldDiff) string {
+	var s string
+
+	for _, f := range d {
+		live := "<nil>"
+		if f.Live != nil {
+			live = *f.Live
+		}
+
+		s += fmt.Sprintf("%s -> %s (%s, %s): %s | %s | %s\n", f.Source, f.Path, f.Transform, f.Note, f.From, f.To, live)
+	}
+
+	return s
+}
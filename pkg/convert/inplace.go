@@ -0,0 +1,344 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Manifest is a YAML file of one or more documents edited in place, keeping
+// the comments and key order of the parts that do not change. Documents that do
+// not change, including empty ones, and the separators between documents are
+// kept as they are. A document that changes is indented again as a whole, so
+// its sequences all take the indentation of its first one.
+type Manifest struct {
+	docs       []*yaml.Node
+	raw        [][]byte
+	seps       []string
+	seqIndents []yaml.SequenceIndentStyle
+	changed    map[int]bool
+	added      map[int][]*yaml.Node
+}
+
+// separatorRE matches a document separator line, and the content following
+// the marker on the same line if any.
+var separatorRE = regexp.MustCompile(`^(---|\.\.\.)(?:[ \t]+(#.*)?)?(?:[ \t]+(.*))?$`)
+
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file: %w", err)
+	}
+
+	m := &Manifest{
+		changed: map[int]bool{},
+		added:   map[int][]*yaml.Node{},
+	}
+
+	var (
+		sep  string
+		body []byte
+	)
+
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		match := separatorRE.FindSubmatchIndex(bytes.TrimRight(line, "\r\n"))
+		if match == nil {
+			body = append(body, line...)
+			continue
+		}
+
+		// consecutive separators stay together in front of the next document.
+		if len(body) != 0 {
+			if err := m.add(sep, body); err != nil {
+				return nil, err
+			}
+
+			sep, body = "", nil
+		}
+
+		if match[6] >= 0 {
+			sep += string(line[:match[6]])
+			body = append(body, line[match[6]:]...)
+		} else {
+			sep += string(line)
+		}
+	}
+
+	if len(body) != 0 || sep != "" {
+		if err := m.add(sep, body); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// add adds the document body preceded by sep. Documents holding only comments
+// are kept as an empty document node.
+func (m *Manifest) add(sep string, body []byte) error {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+
+	if err := yaml.Unmarshal(body, doc); err != nil {
+		return fmt.Errorf("unable to parse document %d: %w", len(m.docs), err)
+	}
+
+	m.docs = append(m.docs, doc)
+	m.raw = append(m.raw, body)
+	m.seps = append(m.seps, sep)
+	m.seqIndents = append(m.seqIndents, yaml.SequenceIndentStyle(yaml.DeriveSeqIndentStyle(string(body))))
+
+	return nil
+}
+
+// Len returns the number of documents of m.
+func (m *Manifest) Len() int {
+	return len(m.docs)
+}
+
+// DeploymentConfig returns the DeploymentConfig in document i, or nil if it
+// holds something else.
+func (m *Manifest) DeploymentConfig(i int) (*ocappsv1.DeploymentConfig, error) {
+	doc := m.docs[i]
+
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	if kind := mappingValue(doc.Content[0], "kind"); kind == nil || kind.Value != "DeploymentConfig" {
+		return nil, nil
+	}
+
+	data, err := yaml.Marshal(doc.Content[0])
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal document %d: %w", i, err)
+	}
+
+	return parseDC(data)
+}
+
+// Replace replaces document i with objs. The first is merged into the
+// document, so unchanged fields keep their comments and position, fields it
+// drops are removed and new ones are added at the end of their mapping. The
+// others become documents of their own after it.
+func (m *Manifest) Replace(i int, objs ...runtime.Object) error {
+	for j, obj := range objs {
+		n, err := toNode(obj)
+		if err != nil {
+			return err
+		}
+
+		if j == 0 {
+			m.docs[i].Content[0] = mergeNode(m.docs[i].Content[0], n)
+			m.changed[i] = true
+
+			continue
+		}
+
+		m.added[i] = append(m.added[i], &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{n}})
+	}
+
+	return nil
+}
+
+// Marshal returns the documents of m with the separators of the file they were
+// loaded from. Documents that changed, and the ones added after them, take the
+// sequence indentation of the document they replace.
+func (m *Manifest) Marshal() ([]byte, error) {
+	var b bytes.Buffer
+
+	for i, doc := range m.docs {
+		if !m.changed[i] {
+			b.WriteString(m.seps[i])
+			b.Write(m.raw[i])
+		} else {
+			// a changed document starts on the line after its separator.
+			if sep := strings.TrimRight(m.seps[i], " \t"); sep != "" && !strings.HasSuffix(sep, "\n") {
+				b.WriteString(sep + "\n")
+			} else {
+				b.WriteString(m.seps[i])
+			}
+
+			if err := encode(&b, doc, m.seqIndents[i]); err != nil {
+				return nil, fmt.Errorf("unable to marshal document %d: %w", i, err)
+			}
+		}
+
+		for _, n := range m.added[i] {
+			if b.Len() != 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+				b.WriteString("\n")
+			}
+
+			b.WriteString("---\n")
+
+			if err := encode(&b, n, m.seqIndents[i]); err != nil {
+				return nil, fmt.Errorf("unable to marshal document %d: %w", i, err)
+			}
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// encode writes the document doc without a separator, indenting sequences in
+// style seqIndent.
+func encode(w io.Writer, doc *yaml.Node, seqIndent yaml.SequenceIndentStyle) error {
+	e := yaml.NewEncoderWithOptions(w, &yaml.EncoderOptions{SeqIndent: seqIndent})
+
+	if err := e.Encode(doc); err != nil {
+		return err
+	}
+
+	return e.Close()
+}
+
+func toNode(obj runtime.Object) (*yaml.Node, error) {
+	data, err := sigsyaml.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal object: %w", err)
+	}
+
+	doc := &yaml.Node{}
+
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("unable to parse object: %w", err)
+	}
+
+	return doc.Content[0], nil
+}
+
+// mergeNode returns old updated to match n. Nodes that stay keep their
+// comments and style. Items of sequences are matched by name when they have
+// one and by position otherwise. Empty fields n adds, such as status: {}, are
+// left out.
+func mergeNode(old, n *yaml.Node) *yaml.Node {
+	switch {
+	case old == nil:
+		return n
+	case old.Kind != n.Kind:
+		n.HeadComment, n.LineComment, n.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		return n
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		var content []*yaml.Node
+
+		for i := 0; i+1 < len(old.Content); i += 2 {
+			if v := mappingValue(n, old.Content[i].Value); v != nil {
+				content = append(content, old.Content[i], mergeNode(old.Content[i+1], v))
+			}
+		}
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if mappingValue(old, n.Content[i].Value) == nil && !isEmpty(n.Content[i+1]) {
+				content = append(content, n.Content[i], n.Content[i+1])
+			}
+		}
+
+		old.Content = content
+	case yaml.SequenceNode:
+		content := make([]*yaml.Node, 0, len(n.Content))
+
+		for i, item := range n.Content {
+			content = append(content, mergeNode(matchItem(old, item, i), item))
+		}
+
+		old.Content = content
+	case yaml.ScalarNode:
+		if old.Value == n.Value && old.ShortTag() == n.ShortTag() {
+			return old
+		}
+
+		quoted := old.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0
+		if !quoted || n.ShortTag() != old.ShortTag() {
+			old.Style = n.Style
+		}
+
+		old.Value = n.Value
+		old.Tag = n.Tag
+	default:
+		n.HeadComment, n.LineComment, n.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		return n
+	}
+
+	return old
+}
+
+// matchItem returns the item of the sequence old matching item, the i-th of
+// the new sequence.
+func matchItem(old, item *yaml.Node, i int) *yaml.Node {
+	if item.Kind == yaml.MappingNode {
+		if name := mappingValue(item, "name"); name != nil {
+			for _, o := range old.Content {
+				if o.Kind == yaml.MappingNode {
+					if n := mappingValue(o, "name"); n != nil && n.Value == name.Value {
+						return o
+					}
+				}
+			}
+
+			return nil
+		}
+	}
+
+	if i < len(old.Content) {
+		return old.Content[i]
+	}
+
+	return nil
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func isEmpty(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		return n.ShortTag() == "!!null"
+	}
+
+	return false
+}
@@ -0,0 +1,196 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestManifestReplace(t *testing.T) {
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name": "app",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "app"},
+			},
+		},
+	}}
+
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": "app-stash",
+		},
+	}}
+
+	withContainers := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name": "app",
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "app"}},
+				},
+			},
+		},
+	}}
+
+	service := "kind: Service\nspec:\n  ports:\n    - port: 80\n"
+
+	compactDC := `apiVersion: apps.openshift.io/v1
+kind: DeploymentConfig
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+`
+
+	compactDeploy := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+`
+
+	dc := `apiVersion: apps.openshift.io/v1 # converted
+kind: DeploymentConfig
+metadata:
+  name: app
+spec:
+  # two replicas
+  replicas: 2
+  selector:
+    app: app
+  triggers:
+    - type: ConfigChange
+`
+
+	deploy := `apiVersion: apps/v1 # converted
+kind: Deployment
+metadata:
+  name: app
+spec:
+  # two replicas
+  replicas: 2
+  selector:
+    matchLabels:
+      app: app
+`
+
+	tests := []struct {
+		name  string
+		input string
+		index int
+		objs  []runtime.Object
+		want  string
+	}{
+		{
+			name:  "single document",
+			input: dc,
+			objs:  []runtime.Object{deployment},
+			want:  deploy,
+		},
+		{
+			name:  "separators and other documents",
+			input: "---\n# first\nkind: Service\n---\n---\n# empty\n--- # dc\n" + dc + "...\n",
+			index: 2,
+			objs:  []runtime.Object{deployment},
+			want:  "---\n# first\nkind: Service\n---\n---\n# empty\n--- # dc\n" + deploy + "...\n",
+		},
+		{
+			name:  "added documents",
+			input: dc + "---\nkind: Service\n",
+			objs:  []runtime.Object{deployment, configMap},
+			want:  deploy + "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-stash\n---\nkind: Service\n",
+		},
+		{
+			name:  "sequence indentation of the document",
+			input: service + "---\n" + compactDC,
+			index: 1,
+			objs:  []runtime.Object{withContainers},
+			want:  service + "---\n" + compactDeploy,
+		},
+		{
+			name:  "unchanged",
+			input: "--- \nkind: Service\n\n\n---\n" + dc,
+			index: -1,
+			want:  "--- \nkind: Service\n\n\n---\n" + dc,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest.yaml")
+
+			if err := os.WriteFile(path, []byte(tt.input), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := LoadManifest(path)
+			if err != nil {
+				t.Fatalf("LoadManifest() error = %v", err)
+			}
+
+			if tt.index >= 0 {
+				dc, err := m.DeploymentConfig(tt.index)
+				if err != nil || dc == nil {
+					t.Fatalf("DeploymentConfig(%d) = %v, %v, want the DeploymentConfig", tt.index, dc, err)
+				}
+
+				if err := m.Replace(tt.index, tt.objs...); err != nil {
+					t.Fatalf("Replace() error = %v", err)
+				}
+			}
+
+			got, err := m.Marshal()
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("Marshal() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -27,18 +27,51 @@ import (
 	"os"
 
 	ocappsv1 "github.com/openshift/api/apps/v1"
+	templatev1 "github.com/openshift/api/template/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
+// Load returns the DeploymentConfig or Template in the file at path.
+func Load(path string) (runtime.Object, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file: %w", err)
+	}
+
+	var tm metav1.TypeMeta
+
+	if err := yaml.Unmarshal(data, &tm); err != nil {
+		return nil, fmt.Errorf("unable to parse file: %w", err)
+	}
+
+	if tm.Kind != "Template" {
+		return parseDC(data)
+	}
+
+	t := &templatev1.Template{}
+
+	if err := yaml.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("unable to build template from file: %w", err)
+	}
+
+	return t, nil
+}
+
 func LoadDC(path string) (*ocappsv1.DeploymentConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read file: %w", err)
 	}
 
+	return parseDC(data)
+}
+
+func parseDC(data []byte) (*ocappsv1.DeploymentConfig, error) {
 	dc := &ocappsv1.DeploymentConfig{}
 
-	err = yaml.Unmarshal(data, dc)
+	err := yaml.Unmarshal(data, dc)
 	if err != nil {
 		return nil, fmt.Errorf("unable to build dc from file: %w", err)
 	}
@@ -0,0 +1,282 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const revisionAnnotationKey = "deployment.kubernetes.io/revision"
+
+var (
+	// serverMetadata are the metadata fields the API server populates.
+	serverMetadata = []string{
+		"uid", "resourceVersion", "generation", "creationTimestamp", "selfLink",
+		"managedFields", "deletionTimestamp", "deletionGracePeriodSeconds",
+	}
+
+	// meaningfulEmpty are the fields whose empty value differs from leaving
+	// them out.
+	meaningfulEmpty = map[string]bool{
+		"emptyDir":          true,
+		"podSelector":       true,
+		"namespaceSelector": true,
+	}
+
+	deploymentDefaults = map[string]interface{}{
+		"replicas":                int64(1),
+		"revisionHistoryLimit":    int64(10),
+		"progressDeadlineSeconds": int64(600),
+	}
+	rollingUpdateDefaults = map[string]interface{}{
+		"maxSurge":       "25%",
+		"maxUnavailable": "25%",
+	}
+	podSpecDefaults = map[string]interface{}{
+		"restartPolicy":                 string(corev1.RestartPolicyAlways),
+		"terminationGracePeriodSeconds": int64(corev1.DefaultTerminationGracePeriodSeconds),
+		"dnsPolicy":                     string(corev1.DNSClusterFirst),
+		"schedulerName":                 corev1.DefaultSchedulerName,
+	}
+	containerDefaults = map[string]interface{}{
+		"terminationMessagePath":   corev1.TerminationMessagePathDefault,
+		"terminationMessagePolicy": string(corev1.TerminationMessageReadFile),
+	}
+	probeDefaults = map[string]interface{}{
+		"timeoutSeconds":   int64(1),
+		"periodSeconds":    int64(10),
+		"successThreshold": int64(1),
+		"failureThreshold": int64(3),
+	}
+	volumeSourceDefaultMode = []string{"configMap", "secret", "projected", "downwardAPI"}
+)
+
+// Minimal returns a copy of obj, or of each item of a List, without the
+// fields the API server populates, the fields equal to the defaults of the
+// API and empty fields. Applying it gives the same object as applying obj.
+func Minimal(obj runtime.Object) (runtime.Object, error) {
+	switch t := obj.(type) {
+	case *corev1.List:
+		l := t.DeepCopy()
+
+		for i, item := range l.Items {
+			var m map[string]interface{}
+
+			if err := json.Unmarshal(item.Raw, &m); err != nil {
+				return nil, fmt.Errorf("unable to parse list item: %w", err)
+			}
+
+			raw, err := json.Marshal(minimal(m))
+			if err != nil {
+				return nil, fmt.Errorf("unable to marshal list item: %w", err)
+			}
+
+			l.Items[i] = runtime.RawExtension{Raw: raw}
+		}
+
+		return l, nil
+	case *unstructured.UnstructuredList:
+		l := t.DeepCopy()
+
+		for i := range l.Items {
+			l.Items[i].Object = minimal(l.Items[i].Object)
+		}
+
+		return l, nil
+	}
+
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj.DeepCopyObject())
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert object: %w", err)
+	}
+
+	return &unstructured.Unstructured{Object: minimal(m)}, nil
+}
+
+func minimal(m map[string]interface{}) map[string]interface{} {
+	delete(m, "status")
+
+	if metadata, ok := m["metadata"].(map[string]interface{}); ok {
+		for _, f := range serverMetadata {
+			delete(metadata, f)
+		}
+
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, revisionAnnotationKey)
+		}
+	}
+
+	if m["kind"] == "Deployment" {
+		spec, _ := m["spec"].(map[string]interface{})
+		dropDefaults(spec, deploymentDefaults)
+
+		if strategy, ok := spec["strategy"].(map[string]interface{}); ok {
+			if rollingUpdate, ok := strategy["rollingUpdate"].(map[string]interface{}); ok {
+				dropDefaults(rollingUpdate, rollingUpdateDefaults)
+
+				if len(rollingUpdate) == 0 {
+					delete(strategy, "rollingUpdate")
+				}
+			}
+
+			if _, ok := strategy["rollingUpdate"]; !ok {
+				dropDefaults(strategy, map[string]interface{}{"type": "RollingUpdate"})
+			}
+		}
+
+		if template, ok := spec["template"].(map[string]interface{}); ok {
+			if podSpec, ok := template["spec"].(map[string]interface{}); ok {
+				dropPodDefaults(podSpec)
+			}
+		}
+	}
+
+	pruned, _ := prune(m).(map[string]interface{})
+	if pruned == nil {
+		pruned = map[string]interface{}{}
+	}
+
+	return pruned
+}
+
+func dropPodDefaults(spec map[string]interface{}) {
+	dropDefaults(spec, podSpecDefaults)
+
+	for _, key := range []string{"initContainers", "containers"} {
+		containers, _ := spec[key].([]interface{})
+
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			dropDefaults(container, containerDefaults)
+
+			if image, ok := container["image"].(string); ok {
+				dropDefaults(container, map[string]interface{}{"imagePullPolicy": defaultPullPolicy(image)})
+			}
+
+			ports, _ := container["ports"].([]interface{})
+			for _, p := range ports {
+				if port, ok := p.(map[string]interface{}); ok {
+					dropDefaults(port, map[string]interface{}{"protocol": string(corev1.ProtocolTCP)})
+				}
+			}
+
+			for _, probe := range []string{"livenessProbe", "readinessProbe", "startupProbe"} {
+				if p, ok := container[probe].(map[string]interface{}); ok {
+					dropDefaults(p, probeDefaults)
+
+					if httpGet, ok := p["httpGet"].(map[string]interface{}); ok {
+						dropDefaults(httpGet, map[string]interface{}{"scheme": string(corev1.URISchemeHTTP)})
+					}
+				}
+			}
+		}
+	}
+
+	volumes, _ := spec["volumes"].([]interface{})
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, source := range volumeSourceDefaultMode {
+			if s, ok := volume[source].(map[string]interface{}); ok {
+				dropDefaults(s, map[string]interface{}{"defaultMode": int64(corev1.ConfigMapVolumeSourceDefaultMode)})
+			}
+		}
+	}
+}
+
+// defaultPullPolicy returns the pull policy the API server defaults for image.
+func defaultPullPolicy(image string) string {
+	if strings.Contains(image, "@") {
+		return string(corev1.PullIfNotPresent)
+	}
+
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i < 0 || name[i+1:] == "latest" {
+		return string(corev1.PullAlways)
+	}
+
+	return string(corev1.PullIfNotPresent)
+}
+
+// dropDefaults removes the fields of m equal to their value in defaults.
+func dropDefaults(m map[string]interface{}, defaults map[string]interface{}) {
+	for k, d := range defaults {
+		if v, ok := m[k]; ok && fmt.Sprint(v) == fmt.Sprint(d) {
+			delete(m, k)
+		}
+	}
+}
+
+// prune removes null, empty map and empty list fields from v, returning nil
+// if v itself is empty.
+func prune(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, mv := range t {
+			p := prune(mv)
+			if p == nil && !(meaningfulEmpty[k] && mv != nil) {
+				delete(t, k)
+				continue
+			}
+
+			if p != nil {
+				t[k] = p
+			}
+		}
+
+		if len(t) == 0 {
+			return nil
+		}
+	case []interface{}:
+		var l []interface{}
+
+		for _, lv := range t {
+			if p := prune(lv); p != nil {
+				l = append(l, p)
+			}
+		}
+
+		if len(l) == 0 {
+			return nil
+		}
+
+		return l
+	case nil:
+		return nil
+	}
+
+	return v
+}
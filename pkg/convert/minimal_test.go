@@ -0,0 +1,222 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+func TestMinimal(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "server fields",
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  uid: 0a1b
+  resourceVersion: "12"
+  creationTimestamp: "2022-01-01T00:00:00Z"
+  annotations:
+    deployment.kubernetes.io/revision: "3"
+data:
+  a: "1"
+status: {}
+`,
+			want: `apiVersion: v1
+data:
+  a: "1"
+kind: ConfigMap
+metadata:
+  name: app
+`,
+		},
+		{
+			name: "deployment defaults",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+  revisionHistoryLimit: 10
+  progressDeadlineSeconds: 600
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 25%
+      maxUnavailable: 25%
+  template:
+    spec:
+      restartPolicy: Always
+      dnsPolicy: ClusterFirst
+      terminationGracePeriodSeconds: 30
+      schedulerName: default-scheduler
+      containers:
+      - name: app
+        image: quay.io/app:1
+        imagePullPolicy: IfNotPresent
+        terminationMessagePath: /dev/termination-log
+        terminationMessagePolicy: File
+        resources: {}
+        ports:
+        - containerPort: 8080
+          protocol: TCP
+        readinessProbe:
+          timeoutSeconds: 1
+          periodSeconds: 10
+          successThreshold: 1
+          failureThreshold: 3
+          httpGet:
+            path: /
+            port: 8080
+            scheme: HTTP
+      volumes:
+      - name: config
+        configMap:
+          name: app
+          defaultMode: 420
+      - name: scratch
+        emptyDir: {}
+`,
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - image: quay.io/app:1
+        name: app
+        ports:
+        - containerPort: 8080
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 8080
+      volumes:
+      - configMap:
+          name: app
+        name: config
+      - emptyDir: {}
+        name: scratch
+`,
+		},
+		{
+			name: "values differing from defaults",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: quay.io/app:latest
+        imagePullPolicy: IfNotPresent
+`,
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+  strategy:
+    rollingUpdate:
+      maxSurge: 1
+    type: RollingUpdate
+  template:
+    spec:
+      containers:
+      - image: quay.io/app:latest
+        imagePullPolicy: IfNotPresent
+        name: app
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+
+			if err := sigsyaml.Unmarshal([]byte(tt.input), &u.Object); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Minimal(u)
+			if err != nil {
+				t.Fatalf("Minimal() error = %v", err)
+			}
+
+			data, err := sigsyaml.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != tt.want {
+				t.Errorf("Minimal() =\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimalList(t *testing.T) {
+	l := &corev1.List{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"},
+		Items: []runtime.RawExtension{
+			{Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","uid":"0a1b"},"data":{}}`)},
+		},
+	}
+
+	got, err := Minimal(l)
+	if err != nil {
+		t.Fatalf("Minimal() error = %v", err)
+	}
+
+	want := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}`
+
+	if raw := string(got.(*corev1.List).Items[0].Raw); raw != want {
+		t.Errorf("Minimal() item = %s, want %s", raw, want)
+	}
+
+	if string(l.Items[0].Raw) == want {
+		t.Error("Minimal() changed its input")
+	}
+}
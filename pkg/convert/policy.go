@@ -0,0 +1,91 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WarningPolicy decides which warnings block a conversion. Denied codes always
+// block, and codes allowed by the policy or named in the object's
+// IgnoreWarningsAnnotationKey annotation never block. Otherwise info warnings
+// never block, blocking warnings always block and warn warnings block unless
+// IgnoreAll is set.
+type WarningPolicy struct {
+	IgnoreAll bool
+	Allow     []string
+	Deny      []string
+}
+
+// Apply sets Allowed on each of w according to the policy for obj and returns
+// the warnings that block.
+func (p *WarningPolicy) Apply(obj metav1.Object, w []*Warning) []*Warning {
+	var blocking []*Warning
+
+	annotated := parseCodes(obj.GetAnnotations()[IgnoreWarningsAnnotationKey])
+
+	for _, warning := range w {
+		switch {
+		case hasCode(p.Deny, warning.Code):
+			warning.Allowed = false
+		case hasCode(p.Allow, warning.Code), hasCode(annotated, warning.Code):
+			warning.Allowed = true
+		case warning.Severity == InfoSeverity:
+			warning.Allowed = true
+		case warning.Severity == BlockingSeverity:
+			warning.Allowed = false
+		default:
+			warning.Allowed = p.IgnoreAll
+		}
+
+		if !warning.Allowed {
+			blocking = append(blocking, warning)
+		}
+	}
+
+	return blocking
+}
+
+func parseCodes(s string) []string {
+	var codes []string
+
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			codes = append(codes, c)
+		}
+	}
+
+	return codes
+}
+
+func hasCode(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
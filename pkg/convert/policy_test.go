@@ -0,0 +1,94 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWarningPolicyApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     WarningPolicy
+		annotation string
+		severity   Severity
+		allowed    bool
+	}{
+		{name: "info", severity: InfoSeverity, allowed: true},
+		{name: "warn", severity: WarnSeverity, allowed: false},
+		{name: "blocking", severity: BlockingSeverity, allowed: false},
+		{name: "warn ignored", policy: WarningPolicy{IgnoreAll: true}, severity: WarnSeverity, allowed: true},
+		{name: "blocking ignored", policy: WarningPolicy{IgnoreAll: true}, severity: BlockingSeverity, allowed: false},
+		{name: "warn annotated", annotation: "Other, Test", severity: WarnSeverity, allowed: true},
+		{name: "warn annotated with other code", annotation: "Other", severity: WarnSeverity, allowed: false},
+		{name: "blocking annotated", annotation: "Test", severity: BlockingSeverity, allowed: true},
+		{name: "denied and annotated", policy: WarningPolicy{Deny: []string{"Test"}}, annotation: "Test", severity: WarnSeverity, allowed: false},
+		{name: "blocking allowed", policy: WarningPolicy{Allow: []string{"Test"}}, severity: BlockingSeverity, allowed: true},
+		{name: "info denied", policy: WarningPolicy{Deny: []string{"Test"}}, severity: InfoSeverity, allowed: false},
+		{name: "denied and ignored", policy: WarningPolicy{IgnoreAll: true, Deny: []string{"Test"}}, severity: WarnSeverity, allowed: false},
+		{name: "denied and allowed", policy: WarningPolicy{Allow: []string{"Test"}, Deny: []string{"Test"}}, severity: WarnSeverity, allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{}
+			if tt.annotation != "" {
+				obj.Annotations = map[string]string{IgnoreWarningsAnnotationKey: tt.annotation}
+			}
+
+			w := &Warning{Code: "Test", Severity: tt.severity}
+
+			blocking := tt.policy.Apply(obj, []*Warning{w})
+
+			if w.Allowed != tt.allowed {
+				t.Errorf("Allowed = %v, want %v", w.Allowed, tt.allowed)
+			}
+
+			if blocked := len(blocking) == 1 && blocking[0] == w; blocked == tt.allowed {
+				t.Errorf("blocking = %v, want blocked %v", blocking, !tt.allowed)
+			}
+		})
+	}
+}
+
+func TestWarningPolicyApplyAnnotation(t *testing.T) {
+	obj := &metav1.ObjectMeta{
+		Annotations: map[string]string{IgnoreWarningsAnnotationKey: "OwnerReference,ChangedLabel"},
+	}
+
+	owner := OwnerReferenceWarning.At(ObjectReference{}, "metadata.ownerReferences[0]", "ReplicaSet/app")
+	label := ChangedLabelWarning.At(ObjectReference{}, "spec.selector", "")
+	test := UnsupportedFeatureTestWarning.At(ObjectReference{}, "spec.test", "true")
+
+	blocking := (&WarningPolicy{}).Apply(obj, []*Warning{owner, label, test})
+
+	if !owner.Allowed || !label.Allowed || test.Allowed {
+		t.Errorf("Allowed = %v, %v, %v, want true, true, false", owner.Allowed, label.Allowed, test.Allowed)
+	}
+
+	if len(blocking) != 1 || blocking[0] != test {
+		t.Errorf("blocking = %v, want only the test warning", blocking)
+	}
+}
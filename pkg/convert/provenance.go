@@ -0,0 +1,180 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+)
+
+type Transform string
+
+const (
+	CopiedTransform    Transform = "copied"
+	RenamedTransform   Transform = "renamed"
+	DerivedTransform   Transform = "derived"
+	DefaultedTransform Transform = "defaulted"
+	DroppedTransform   Transform = "dropped"
+)
+
+// FieldProvenance describes where an output field came from. Dropped fields
+// have no Path and defaulted fields have no Source.
+type FieldProvenance struct {
+	Path      string    `json:"path,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Transform Transform `json:"transform"`
+}
+
+type Provenance []*FieldProvenance
+
+func (p *Provenance) add(path, source string, t Transform) {
+	*p = append(*p, &FieldProvenance{
+		Path:      path,
+		Source:    source,
+		Transform: t,
+	})
+}
+
+// set replaces the provenance of path, adding it if it is not recorded yet.
+func (p *Provenance) set(path, source string, t Transform) {
+	for _, f := range *p {
+		if f.Path == path {
+			f.Source = source
+			f.Transform = t
+
+			return
+		}
+	}
+
+	p.add(path, source, t)
+}
+
+func (p *Provenance) addLabels(path, source string, l map[string]string) {
+	for _, k := range sortedKeys(l) {
+		if v, ok := ReplaceLabels[k]; ok {
+			p.add(FieldPath(path, v), FieldPath(source, k), RenamedTransform)
+		} else {
+			p.add(FieldPath(path, k), FieldPath(source, k), CopiedTransform)
+		}
+	}
+}
+
+func (p *Provenance) addAnnotations(path, source string, a map[string]string) {
+	for _, k := range sortedKeys(a) {
+		if isStripped(k) {
+			p.add("", FieldPath(source, k), DroppedTransform)
+		} else {
+			p.add(FieldPath(path, k), FieldPath(source, k), CopiedTransform)
+		}
+	}
+}
+
+// addDropped records the DeploymentConfigSpec fields ToDeploy has no
+// Deployment equivalent for.
+func (p *Provenance) addDropped(dc *ocappsv1.DeploymentConfig) {
+	s := dc.Spec.Strategy
+
+	if s.Type == ocappsv1.DeploymentStrategyTypeCustom {
+		p.add("", "spec.strategy.customParams", DroppedTransform)
+	}
+
+	if s.RollingParams != nil {
+		if s.RollingParams.IntervalSeconds != nil {
+			p.add("", "spec.strategy.rollingParams.intervalSeconds", DroppedTransform)
+		}
+
+		if s.RollingParams.UpdatePeriodSeconds != nil {
+			p.add("", "spec.strategy.rollingParams.updatePeriodSeconds", DroppedTransform)
+		}
+
+		if s.RollingParams.Pre != nil {
+			p.add("", "spec.strategy.rollingParams.pre", DroppedTransform)
+		}
+
+		if s.RollingParams.Post != nil {
+			p.add("", "spec.strategy.rollingParams.post", DroppedTransform)
+		}
+	}
+
+	if s.RecreateParams != nil {
+		if s.RecreateParams.Pre != nil {
+			p.add("", "spec.strategy.recreateParams.pre", DroppedTransform)
+		}
+
+		if s.RecreateParams.Mid != nil {
+			p.add("", "spec.strategy.recreateParams.mid", DroppedTransform)
+		}
+
+		if s.RecreateParams.Post != nil {
+			p.add("", "spec.strategy.recreateParams.post", DroppedTransform)
+		}
+	}
+
+	if s.ActiveDeadlineSeconds != nil {
+		p.add("", "spec.strategy.activeDeadlineSeconds", DroppedTransform)
+	}
+
+	if len(s.Resources.Limits) != 0 || len(s.Resources.Requests) != 0 {
+		p.add("", "spec.strategy.resources", DroppedTransform)
+	}
+
+	if len(s.Labels) != 0 {
+		p.add("", "spec.strategy.labels", DroppedTransform)
+	}
+
+	if len(s.Annotations) != 0 {
+		p.add("", "spec.strategy.annotations", DroppedTransform)
+	}
+
+	if dc.Spec.Test {
+		p.add("", "spec.test", DroppedTransform)
+	}
+
+	for i, t := range dc.Spec.Triggers {
+		if t.Type != ocappsv1.DeploymentTriggerOnImageChange {
+			p.add("", fmt.Sprintf("spec.triggers[%d]", i), DroppedTransform)
+		}
+	}
+}
+
+// FieldPath appends key to path, quoting keys that are not plain identifiers
+// such as annotation and label keys.
+func FieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s['%s']", path, key)
+	}
+
+	return path + "." + key
+}
+
+func isStripped(key string) bool {
+	for _, s := range StripAnnotations {
+		if s == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reference is an object a pod template depends on. Path is the field of the
+// pod spec the reference was found at.
+type Reference struct {
+	Kind     string
+	Name     string
+	Path     string
+	Optional bool
+}
+
+// References returns the ServiceAccount, Secrets, ConfigMaps and
+// PersistentVolumeClaims referenced by spec, with paths prefixed by path.
+func References(path string, spec *corev1.PodSpec) []Reference {
+	var refs []Reference
+
+	add := func(kind, name, p string, optional *bool) {
+		refs = appendReference(refs, kind, name, p, optional)
+	}
+
+	add("ServiceAccount", spec.ServiceAccountName, path+".serviceAccountName", nil)
+
+	for i, s := range spec.ImagePullSecrets {
+		add("Secret", s.Name, fmt.Sprintf("%s.imagePullSecrets[%d].name", path, i), nil)
+	}
+
+	for i, v := range spec.Volumes {
+		vp := fmt.Sprintf("%s.volumes[%d]", path, i)
+
+		switch {
+		case v.Secret != nil:
+			add("Secret", v.Secret.SecretName, vp+".secret.secretName", v.Secret.Optional)
+		case v.ConfigMap != nil:
+			add("ConfigMap", v.ConfigMap.Name, vp+".configMap.name", v.ConfigMap.Optional)
+		case v.PersistentVolumeClaim != nil:
+			add("PersistentVolumeClaim", v.PersistentVolumeClaim.ClaimName, vp+".persistentVolumeClaim.claimName", nil)
+		case v.Projected != nil:
+			for j, s := range v.Projected.Sources {
+				sp := fmt.Sprintf("%s.projected.sources[%d]", vp, j)
+
+				if s.Secret != nil {
+					add("Secret", s.Secret.Name, sp+".secret.name", s.Secret.Optional)
+				}
+
+				if s.ConfigMap != nil {
+					add("ConfigMap", s.ConfigMap.Name, sp+".configMap.name", s.ConfigMap.Optional)
+				}
+			}
+		}
+	}
+
+	refs = append(refs, containerReferences(path+".initContainers", spec.InitContainers)...)
+	refs = append(refs, containerReferences(path+".containers", spec.Containers)...)
+
+	return refs
+}
+
+func containerReferences(path string, containers []corev1.Container) []Reference {
+	var refs []Reference
+
+	for i, c := range containers {
+		cp := fmt.Sprintf("%s[%d]", path, i)
+
+		for j, e := range c.EnvFrom {
+			ep := fmt.Sprintf("%s.envFrom[%d]", cp, j)
+
+			if e.SecretRef != nil {
+				refs = appendReference(refs, "Secret", e.SecretRef.Name, ep+".secretRef.name", e.SecretRef.Optional)
+			}
+
+			if e.ConfigMapRef != nil {
+				refs = appendReference(refs, "ConfigMap", e.ConfigMapRef.Name, ep+".configMapRef.name", e.ConfigMapRef.Optional)
+			}
+		}
+
+		for j, e := range c.Env {
+			if e.ValueFrom == nil {
+				continue
+			}
+
+			ep := fmt.Sprintf("%s.env[%d].valueFrom", cp, j)
+
+			if r := e.ValueFrom.SecretKeyRef; r != nil {
+				refs = appendReference(refs, "Secret", r.Name, ep+".secretKeyRef.name", r.Optional)
+			}
+
+			if r := e.ValueFrom.ConfigMapKeyRef; r != nil {
+				refs = appendReference(refs, "ConfigMap", r.Name, ep+".configMapKeyRef.name", r.Optional)
+			}
+		}
+	}
+
+	return refs
+}
+
+func appendReference(refs []Reference, kind, name, path string, optional *bool) []Reference {
+	if name == "" {
+		return refs
+	}
+
+	return append(refs, Reference{
+		Kind:     kind,
+		Name:     name,
+		Path:     path,
+		Optional: optional != nil && *optional,
+	})
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Relocate moves deploy to namespace, when set, and rewrites the registry of
+// container images starting with a key of registries to its value, recording
+// the changes in p. The longest matching registry wins.
+func Relocate(deploy *appsv1.Deployment, p *Provenance, namespace string, registries map[string]string) {
+	if namespace != "" && namespace != deploy.Namespace {
+		deploy.Namespace = namespace
+		p.set("metadata.namespace", "metadata.namespace", DerivedTransform)
+	}
+
+	if len(registries) == 0 {
+		return
+	}
+
+	spec := &deploy.Spec.Template.Spec
+
+	for i := range spec.InitContainers {
+		relocateImage(&spec.InitContainers[i], p, fmt.Sprintf("spec.template.spec.initContainers[%d].image", i), registries)
+	}
+
+	for i := range spec.Containers {
+		relocateImage(&spec.Containers[i], p, fmt.Sprintf("spec.template.spec.containers[%d].image", i), registries)
+	}
+}
+
+func relocateImage(c *corev1.Container, p *Provenance, path string, registries map[string]string) {
+	var from string
+
+	for k := range registries {
+		k = strings.TrimSuffix(k, "/")
+
+		if len(k) > len(from) && strings.HasPrefix(c.Image, k+"/") {
+			from = k
+		}
+	}
+
+	if from == "" {
+		return
+	}
+
+	to, ok := registries[from]
+	if !ok {
+		to = registries[from+"/"]
+	}
+
+	c.Image = strings.TrimSuffix(to, "/") + strings.TrimPrefix(c.Image, from)
+	p.set(path, path, DerivedTransform)
+}
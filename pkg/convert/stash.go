@@ -0,0 +1,297 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type StashMode string
+
+const (
+	NoStash         StashMode = ""
+	AnnotationStash StashMode = "annotation"
+	ConfigMapStash  StashMode = "configmap"
+)
+
+// Stash records the parts of a DeploymentConfigSpec that ToDeploy can not map
+// onto a Deployment, or changes on the way, so a reverse conversion can restore
+// them.
+type Stash struct {
+	Strategy *ocappsv1.DeploymentStrategy       `json:"strategy,omitempty"`
+	Triggers ocappsv1.DeploymentTriggerPolicies `json:"triggers,omitempty"`
+	Test     bool                               `json:"test,omitempty"`
+
+	// SelectorLabels and TemplateLabels map the keys of the labels renamed in
+	// the selector and the pod template to their DeploymentConfig keys.
+	SelectorLabels map[string]string `json:"selectorLabels,omitempty"`
+	TemplateLabels map[string]string `json:"templateLabels,omitempty"`
+
+	// TemplateAnnotations holds the pod template annotations that are
+	// stripped.
+	TemplateAnnotations map[string]string `json:"templateAnnotations,omitempty"`
+}
+
+func NewStash(orig *ocappsv1.DeploymentConfig) *Stash {
+	s := &Stash{
+		Strategy:       unmappedStrategy(&orig.Spec.Strategy),
+		Triggers:       orig.Spec.Triggers.DeepCopy(),
+		Test:           orig.Spec.Test,
+		SelectorLabels: renamedLabels(orig.Spec.Selector),
+	}
+
+	if orig.Spec.Template != nil {
+		s.TemplateLabels = renamedLabels(orig.Spec.Template.Labels)
+		s.TemplateAnnotations = strippedAnnotations(orig.Spec.Template.Annotations)
+	}
+
+	if s.Strategy == nil && s.Triggers == nil && !s.Test &&
+		s.SelectorLabels == nil && s.TemplateLabels == nil && s.TemplateAnnotations == nil {
+		return nil
+	}
+
+	return s
+}
+
+// Restore puts the stashed fields back on dc, a DeploymentConfig converted back
+// from the Deployment. The strategy fields the Deployment holds are kept.
+func (s *Stash) Restore(dc *ocappsv1.DeploymentConfig) {
+	if s == nil {
+		return
+	}
+
+	if s.Strategy != nil {
+		strategy := s.Strategy.DeepCopy()
+
+		if r := dc.Spec.Strategy.RollingParams; r != nil {
+			if strategy.RollingParams == nil {
+				strategy.RollingParams = &ocappsv1.RollingDeploymentStrategyParams{}
+			}
+
+			strategy.RollingParams.MaxSurge = r.MaxSurge
+			strategy.RollingParams.MaxUnavailable = r.MaxUnavailable
+			strategy.RollingParams.TimeoutSeconds = r.TimeoutSeconds
+		}
+
+		if r := dc.Spec.Strategy.RecreateParams; r != nil {
+			if strategy.RecreateParams == nil {
+				strategy.RecreateParams = &ocappsv1.RecreateDeploymentStrategyParams{}
+			}
+
+			strategy.RecreateParams.TimeoutSeconds = r.TimeoutSeconds
+		}
+
+		dc.Spec.Strategy = *strategy
+	}
+
+	dc.Spec.Triggers = s.Triggers.DeepCopy()
+	dc.Spec.Test = s.Test
+	dc.Spec.Selector = restoreLabels(dc.Spec.Selector, s.SelectorLabels)
+
+	if dc.Spec.Template == nil {
+		return
+	}
+
+	dc.Spec.Template.Labels = restoreLabels(dc.Spec.Template.Labels, s.TemplateLabels)
+
+	for k, v := range s.TemplateAnnotations {
+		if dc.Spec.Template.Annotations == nil {
+			dc.Spec.Template.Annotations = map[string]string{}
+		}
+
+		dc.Spec.Template.Annotations[k] = v
+	}
+}
+
+// LoadStash returns the stash recorded on deploy by an AnnotationStash, or in
+// the data of cm by a ConfigMapStash. It returns nil if there is none.
+func LoadStash(deploy *appsv1.Deployment, cm *corev1.ConfigMap) (*Stash, error) {
+	data, ok := deploy.Annotations[StashAnnotationKey]
+	if !ok && cm != nil {
+		data, ok = cm.Data[StashConfigMapKey]
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	s := &Stash{}
+
+	if err := json.Unmarshal([]byte(data), s); err != nil {
+		return nil, fmt.Errorf("unable to parse stash: %w", err)
+	}
+
+	return s, nil
+}
+
+// Apply stores the stash on deploy. With ConfigMapStash the stash is returned
+// as a ConfigMap and deploy is annotated with its name.
+func (s *Stash) Apply(deploy *appsv1.Deployment, mode StashMode) (*corev1.ConfigMap, error) {
+	if s == nil || mode == NoStash {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal stash: %w", err)
+	}
+
+	if deploy.Annotations == nil {
+		deploy.Annotations = make(map[string]string)
+	}
+
+	switch mode {
+	case AnnotationStash:
+		deploy.Annotations[StashAnnotationKey] = string(data)
+
+		return nil, nil
+	case ConfigMapStash:
+		cm := &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      deploy.Name + StashConfigMapSuffix,
+				Namespace: deploy.Namespace,
+				Labels:    deploy.Labels,
+			},
+			Data: map[string]string{
+				StashConfigMapKey: string(data),
+			},
+		}
+		deploy.Annotations[StashConfigMapAnnotationKey] = cm.Name
+
+		return cm, nil
+	default:
+		return nil, fmt.Errorf("unknown stash mode: %s (use annotation or configmap)", mode)
+	}
+}
+
+// renamedLabels maps the keys cleanLabels gives the labels of l it renames to
+// their keys in l.
+func renamedLabels(l map[string]string) map[string]string {
+	var result map[string]string
+
+	for from, to := range ReplaceLabels {
+		if _, ok := l[from]; !ok {
+			continue
+		}
+
+		if result == nil {
+			result = map[string]string{}
+		}
+
+		result[to] = from
+	}
+
+	return result
+}
+
+func restoreLabels(l map[string]string, renamed map[string]string) map[string]string {
+	if len(renamed) == 0 {
+		return l
+	}
+
+	result := make(map[string]string, len(l))
+
+	for k, v := range l {
+		if from, ok := renamed[k]; ok {
+			k = from
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
+
+// strippedAnnotations returns the annotations of a that cleanAnnotations
+// strips.
+func strippedAnnotations(a map[string]string) map[string]string {
+	var result map[string]string
+
+	for _, k := range StripAnnotations {
+		v, ok := a[k]
+		if !ok {
+			continue
+		}
+
+		if result == nil {
+			result = map[string]string{}
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
+
+func unmappedStrategy(orig *ocappsv1.DeploymentStrategy) *ocappsv1.DeploymentStrategy {
+	s := orig.DeepCopy()
+	empty := true
+
+	if s.RollingParams != nil {
+		s.RollingParams.MaxSurge = nil
+		s.RollingParams.MaxUnavailable = nil
+		s.RollingParams.TimeoutSeconds = nil
+
+		if *s.RollingParams == (ocappsv1.RollingDeploymentStrategyParams{}) {
+			s.RollingParams = nil
+		} else {
+			empty = false
+		}
+	}
+
+	if s.RecreateParams != nil {
+		s.RecreateParams.TimeoutSeconds = nil
+
+		if *s.RecreateParams == (ocappsv1.RecreateDeploymentStrategyParams{}) {
+			s.RecreateParams = nil
+		} else {
+			empty = false
+		}
+	}
+
+	if s.Type == ocappsv1.DeploymentStrategyTypeCustom ||
+		s.CustomParams != nil ||
+		s.ActiveDeadlineSeconds != nil ||
+		len(s.Labels) != 0 ||
+		len(s.Annotations) != 0 ||
+		len(s.Resources.Limits) != 0 ||
+		len(s.Resources.Requests) != 0 {
+		empty = false
+	}
+
+	if empty {
+		return nil
+	}
+
+	return s
+}
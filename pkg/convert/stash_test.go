@@ -0,0 +1,125 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// fromDeploy converts the fields of deploy that map onto a DeploymentConfig
+// back, as a reverse conversion would before restoring the stash.
+func fromDeploy(deploy *appsv1.Deployment) *ocappsv1.DeploymentConfig {
+	dc := &ocappsv1.DeploymentConfig{
+		ObjectMeta: *deploy.ObjectMeta.DeepCopy(),
+		Spec: ocappsv1.DeploymentConfigSpec{
+			Replicas: *deploy.Spec.Replicas,
+			Selector: deploy.Spec.Selector.MatchLabels,
+			Template: deploy.Spec.Template.DeepCopy(),
+			Strategy: ocappsv1.DeploymentStrategy{Type: ocappsv1.DeploymentStrategyTypeRolling},
+		},
+	}
+
+	if r := deploy.Spec.Strategy.RollingUpdate; r != nil {
+		dc.Spec.Strategy.RollingParams = &ocappsv1.RollingDeploymentStrategyParams{
+			MaxSurge:       r.MaxSurge,
+			MaxUnavailable: r.MaxUnavailable,
+		}
+	}
+
+	return dc
+}
+
+func TestStashRoundTrip(t *testing.T) {
+	surge := intstr.FromString("50%")
+	interval := int64(2)
+
+	orig := &ocappsv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+		Spec: ocappsv1.DeploymentConfigSpec{
+			Replicas: 2,
+			Test:     true,
+			Selector: map[string]string{DeploymentConfigPodLabel: "app", "app": "app"},
+			Strategy: ocappsv1.DeploymentStrategy{
+				Type: ocappsv1.DeploymentStrategyTypeRolling,
+				RollingParams: &ocappsv1.RollingDeploymentStrategyParams{
+					MaxSurge:        &surge,
+					IntervalSeconds: &interval,
+					Pre: &ocappsv1.LifecycleHook{
+						FailurePolicy: ocappsv1.LifecycleHookFailurePolicyAbort,
+						ExecNewPod: &ocappsv1.ExecNewPodHook{
+							ContainerName: "app",
+							Command:       []string{"/bin/migrate"},
+						},
+					},
+				},
+				Labels: map[string]string{"hook": "true"},
+			},
+			Triggers: ocappsv1.DeploymentTriggerPolicies{{Type: ocappsv1.DeploymentTriggerOnConfigChange}},
+			Template: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{DeploymentConfigPodLabel: "app", "app": "app"},
+					Annotations: map[string]string{
+						GeneratedByAnnotationKey: "OpenShiftNewApp",
+						"team":                   "web",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "quay.io/app:1"}},
+				},
+			},
+		},
+	}
+
+	for _, mode := range []StashMode{AnnotationStash, ConfigMapStash} {
+		t.Run(string(mode), func(t *testing.T) {
+			deploy, err := ToDeploy(orig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cm, err := NewStash(orig).Apply(deploy, mode)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+
+			s, err := LoadStash(deploy, cm)
+			if err != nil {
+				t.Fatalf("LoadStash() error = %v", err)
+			}
+
+			dc := fromDeploy(deploy)
+			s.Restore(dc)
+
+			if !reflect.DeepEqual(dc.Spec, orig.Spec) {
+				t.Errorf("restored spec = %+v, want %+v", dc.Spec, orig.Spec)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// sentinelBase is the first number standing in for a parameter reference in a
+// numeric field. Sentinels stay within int32 so they survive every numeric
+// field of a DeploymentConfig and Deployment.
+const sentinelBase = 2147000000
+
+var (
+	stringParameterRE = regexp.MustCompile(`^\$\{[a-zA-Z0-9_]+\}$`)
+
+	quantityType    = reflect.TypeOf(resource.Quantity{})
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+	rawType         = reflect.TypeOf(runtime.RawExtension{})
+)
+
+// Placeholders maps the sentinels standing in for template parameter
+// references, such as ${REPLICAS}, in fields that cannot hold them as a
+// string back to the references. References in string fields are left as they
+// are.
+type Placeholders struct {
+	sentinels map[string]string
+}
+
+// TemplateDC returns the DeploymentConfig in raw, an object of a Template, with
+// the parameter references in its numeric and quantity fields replaced by
+// sentinels, or nil if raw is not a DeploymentConfig. References in boolean
+// fields cannot be preserved and are an error.
+func TemplateDC(raw runtime.RawExtension) (*ocappsv1.DeploymentConfig, *Placeholders, error) {
+	var obj map[string]interface{}
+
+	if err := json.Unmarshal(raw.Raw, &obj); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse template object: %w", err)
+	}
+
+	if obj["kind"] != "DeploymentConfig" {
+		return nil, nil, nil
+	}
+
+	p := &Placeholders{sentinels: map[string]string{}}
+
+	v, err := p.replace(obj, reflect.TypeOf(ocappsv1.DeploymentConfig{}), "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to marshal template object: %w", err)
+	}
+
+	dc := &ocappsv1.DeploymentConfig{}
+
+	if err := json.Unmarshal(data, dc); err != nil {
+		return nil, nil, fmt.Errorf("unable to build dc from template object: %w", err)
+	}
+
+	// Templates may still use the legacy v1 group.
+	dc.APIVersion = ocappsv1.GroupVersion.String()
+
+	return dc, p, nil
+}
+
+// Restore returns obj as a template object with the sentinels replaced by the
+// parameter references they stand in for.
+func (p *Placeholders) Restore(obj interface{}) (runtime.RawExtension, error) {
+	return p.restoreObject(obj, false)
+}
+
+// RestoreTyped is Restore, but a ${NAME} reference restored into a numeric
+// field takes the ${{NAME}} form, so consumers that do not know the field types
+// insert the value of the parameter without quoting it.
+func (p *Placeholders) RestoreTyped(obj interface{}) (runtime.RawExtension, error) {
+	return p.restoreObject(obj, true)
+}
+
+// RestoreValue returns s, a value as Diff formats it, with the sentinels
+// replaced by the parameter references they stand in for.
+func (p *Placeholders) RestoreValue(s string) string {
+	if strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[") {
+		var v interface{}
+
+		d := json.NewDecoder(strings.NewReader(s))
+		d.UseNumber()
+
+		if err := d.Decode(&v); err == nil {
+			if data, err := json.Marshal(p.restore(v, false)); err == nil {
+				return string(data)
+			}
+		}
+	}
+
+	if r, ok := p.restore(s, false).(string); ok {
+		return r
+	}
+
+	return s
+}
+
+func (p *Placeholders) restoreObject(obj interface{}, typed bool) (runtime.RawExtension, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return runtime.RawExtension{}, fmt.Errorf("unable to marshal template object: %w", err)
+	}
+
+	var v interface{}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	if err := d.Decode(&v); err != nil {
+		return runtime.RawExtension{}, fmt.Errorf("unable to parse template object: %w", err)
+	}
+
+	data, err = json.Marshal(p.restore(v, typed))
+	if err != nil {
+		return runtime.RawExtension{}, fmt.Errorf("unable to marshal template object: %w", err)
+	}
+
+	return runtime.RawExtension{Raw: data}, nil
+}
+
+// replace walks v, the JSON form of a value of type t, replacing parameter
+// references where t does not allow a string.
+func (p *Placeholders) replace(v interface{}, t reflect.Type, path string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	s, isString := v.(string)
+	if isString && !strings.Contains(s, "${") {
+		return v, nil
+	}
+
+	switch t {
+	case intOrStringType, rawType:
+		return v, nil
+	case quantityType:
+		if isString {
+			return p.sentinel(s), nil
+		}
+
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v, nil
+		}
+
+		fields := jsonFields(t)
+
+		for k, fv := range m {
+			ft, ok := fields[k]
+			if !ok {
+				continue
+			}
+
+			r, err := p.replace(fv, ft, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+
+			m[k] = r
+		}
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v, nil
+		}
+
+		for k, mv := range m {
+			r, err := p.replace(mv, t.Elem(), FieldPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+
+			m[k] = r
+		}
+	case reflect.Slice:
+		l, ok := v.([]interface{})
+		if !ok {
+			return v, nil
+		}
+
+		for i, lv := range l {
+			r, err := p.replace(lv, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+
+			l[i] = r
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if isString {
+			n, err := strconv.ParseInt(p.sentinel(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unable to replace %s at %s: %w", s, path, err)
+			}
+
+			return n, nil
+		}
+	case reflect.Bool:
+		if isString {
+			return nil, fmt.Errorf("unable to preserve parameter %s in boolean field %s", s, path)
+		}
+	}
+
+	return v, nil
+}
+
+func (p *Placeholders) sentinel(ref string) string {
+	s := strconv.Itoa(sentinelBase + len(p.sentinels))
+	p.sentinels[s] = ref
+
+	return s
+}
+
+func (p *Placeholders) restore(v interface{}, typed bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, mv := range t {
+			t[k] = p.restore(mv, typed)
+		}
+	case []interface{}:
+		for i, lv := range t {
+			t[i] = p.restore(lv, typed)
+		}
+	case json.Number:
+		ref, ok := p.sentinels[t.String()]
+		if !ok {
+			break
+		}
+
+		if typed && stringParameterRE.MatchString(ref) {
+			return "${{" + ref[2:len(ref)-1] + "}}"
+		}
+
+		return ref
+	case string:
+		if ref, ok := p.sentinels[t]; ok {
+			return ref
+		}
+
+		// quantities are marshalled in their canonical form, such as 2147M.
+		if q, err := resource.ParseQuantity(t); err == nil {
+			if ref, ok := p.sentinels[strconv.FormatInt(q.Value(), 10)]; ok {
+				return ref
+			}
+		}
+	}
+
+	return v
+}
+
+// jsonFields returns the types of the fields of struct type t by JSON name,
+// including the fields of inlined structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+		switch {
+		case name == "-":
+			continue
+		case f.Anonymous && (name == "" || strings.Contains(opts, "inline")):
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			for k, v := range jsonFields(ft) {
+				fields[k] = v
+			}
+		case name == "":
+			fields[f.Name] = f.Type
+		default:
+			fields[name] = f.Type
+		}
+	}
+
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const templateDCJSON = `{
+	"apiVersion": "v1",
+	"kind": "DeploymentConfig",
+	"metadata": {"name": "${NAME}"},
+	"spec": {
+		"replicas": %s,
+		"template": {"spec": {"containers": [{
+			"name": "app",
+			"image": "quay.io/app:${TAG}",
+			"resources": {"requests": {"cpu": "${CPU}"}}
+		}]}}
+	}
+}`
+
+func TestTemplateDC(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas string
+		restored string
+		typed    string
+	}{
+		{name: "literal", replicas: `2`, restored: "2", typed: "2"},
+		{name: "string reference", replicas: `"${REPLICAS}"`, restored: "${REPLICAS}", typed: "${{REPLICAS}}"},
+		{name: "typed reference", replicas: `"${{REPLICAS}}"`, restored: "${{REPLICAS}}", typed: "${{REPLICAS}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := runtime.RawExtension{Raw: []byte(fmt.Sprintf(templateDCJSON, tt.replicas))}
+
+			dc, p, err := TemplateDC(raw)
+			if err != nil {
+				t.Fatalf("TemplateDC() error = %v", err)
+			}
+
+			if dc.Name != "${NAME}" || dc.Spec.Template.Spec.Containers[0].Image != "quay.io/app:${TAG}" {
+				t.Errorf("string fields = %q, %q, want references kept", dc.Name, dc.Spec.Template.Spec.Containers[0].Image)
+			}
+
+			for _, c := range []struct {
+				restore  func(interface{}) (runtime.RawExtension, error)
+				replicas string
+			}{
+				{p.Restore, tt.restored},
+				{p.RestoreTyped, tt.typed},
+			} {
+				restored, err := c.restore(dc)
+				if err != nil {
+					t.Fatalf("restore error = %v", err)
+				}
+
+				var obj struct {
+					Spec struct {
+						Replicas json.RawMessage `json:"replicas"`
+						Template struct {
+							Spec struct {
+								Containers []struct {
+									Resources struct {
+										Requests map[string]string `json:"requests"`
+									} `json:"resources"`
+								} `json:"containers"`
+							} `json:"spec"`
+						} `json:"template"`
+					} `json:"spec"`
+				}
+
+				if err := json.Unmarshal(restored.Raw, &obj); err != nil {
+					t.Fatalf("unable to parse restored object: %v", err)
+				}
+
+				var replicas interface{}
+
+				if err := json.Unmarshal(obj.Spec.Replicas, &replicas); err != nil {
+					t.Fatalf("unable to parse replicas: %v", err)
+				}
+
+				if got := fmt.Sprint(replicas); got != c.replicas {
+					t.Errorf("replicas = %s, want %s", got, c.replicas)
+				}
+
+				if got := obj.Spec.Template.Spec.Containers[0].Resources.Requests["cpu"]; got != "${CPU}" {
+					t.Errorf("cpu = %s, want ${CPU}", got)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateDCErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		err  bool
+	}{
+		{name: "other kind", raw: `{"apiVersion": "v1", "kind": "Service"}`},
+		{name: "boolean reference", raw: `{"kind": "DeploymentConfig", "spec": {"paused": "${PAUSED}"}}`, err: true},
+		{name: "invalid", raw: `{`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc, _, err := TemplateDC(runtime.RawExtension{Raw: []byte(tt.raw)})
+			if (err != nil) != tt.err {
+				t.Fatalf("TemplateDC() error = %v, want error %v", err, tt.err)
+			}
+
+			if dc != nil {
+				t.Errorf("TemplateDC() = %v, want nil", dc)
+			}
+		})
+	}
+}
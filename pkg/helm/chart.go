@@ -0,0 +1,547 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// parameterRE matches the ${NAME} and ${{NAME}} references to template
+	// parameters.
+	parameterRE = regexp.MustCompile(`\$\{(?:\{([a-zA-Z0-9_]+)\}|([a-zA-Z0-9_]+))\}`)
+	tokenRE     = regexp.MustCompile(`__helm_([0-9]+)__`)
+	identRE     = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	fileNameRE  = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// Chart is a Helm chart built from converted objects. References to template
+// parameters become references to values, and the replicas, images and
+// resources of Deployments become values under deployments.<name> unless they
+// already use a parameter.
+type Chart struct {
+	Name        string
+	Description string
+
+	parameters []templatev1.Parameter
+	labels     map[string]string
+	templates  map[string][]byte
+	exprs      []expr
+
+	// deployments maps the keys of Deployments to their values, which are
+	// nested under their own key so they cannot collide with parameters.
+	deployments map[string]interface{}
+
+	// images maps the images moved to values to the template actions
+	// referencing them.
+	images map[string]string
+}
+
+// expr is a template action standing in for a value. Block actions render a
+// YAML document and go on their own lines.
+type expr struct {
+	text  string
+	block bool
+}
+
+func NewChart(name string) *Chart {
+	return &Chart{
+		Name:        name,
+		Description: "Converted from OpenShift DeploymentConfigs",
+		templates:   map[string][]byte{},
+		deployments: map[string]interface{}{},
+		images:      map[string]string{},
+	}
+}
+
+// SetTemplate makes the parameters of t values of the chart and applies its
+// object labels to every object added afterwards.
+func (c *Chart) SetTemplate(t *templatev1.Template) {
+	c.parameters = t.Parameters
+	c.labels = t.ObjectLabels
+
+	if d := t.Annotations["description"]; d != "" {
+		c.Description = d
+	}
+}
+
+// Add adds obj, any object marshalling to a Kubernetes object, as a template
+// of the chart. Its namespace is dropped so it is installed in the namespace of
+// the release.
+func (c *Chart) Add(obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("unable to marshal object: %w", err)
+	}
+
+	var u map[string]interface{}
+
+	if err := json.Unmarshal(data, &u); err != nil {
+		return fmt.Errorf("unable to parse object: %w", err)
+	}
+
+	kind, _ := u["kind"].(string)
+	metadata, _ := u["metadata"].(map[string]interface{})
+
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		u["metadata"] = metadata
+	}
+
+	name, _ := metadata["name"].(string)
+
+	delete(metadata, "namespace")
+	delete(metadata, "creationTimestamp")
+	delete(u, "status")
+
+	if len(c.labels) != 0 {
+		labels, _ := metadata["labels"].(map[string]interface{})
+		if labels == nil {
+			labels = map[string]interface{}{}
+		}
+
+		for k, v := range c.labels {
+			labels[k] = v
+		}
+
+		metadata["labels"] = labels
+	}
+
+	switch kind {
+	case "Deployment":
+		c.templateDeployment(u, name)
+	case "Job":
+		c.templateJob(u)
+	}
+
+	out, err := yaml.Marshal(c.replace(u))
+	if err != nil {
+		return fmt.Errorf("unable to marshal %s %s: %w", kind, name, err)
+	}
+
+	c.templates[c.fileName(kind, name)] = c.expand(out)
+
+	return nil
+}
+
+// Write writes the chart to dir, creating it if needed.
+func (c *Chart) Write(dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		return err
+	}
+
+	meta, err := yaml.Marshal(map[string]string{
+		"apiVersion":  "v2",
+		"name":        c.Name,
+		"description": c.Description,
+		"type":        "application",
+		"version":     "0.1.0",
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), meta, 0644); err != nil {
+		return err
+	}
+
+	values, err := c.valuesYAML()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "values.yaml"), values, 0644); err != nil {
+		return err
+	}
+
+	for name, data := range c.templates {
+		if err := os.WriteFile(filepath.Join(dir, "templates", name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// templateDeployment moves the replicas, and the images and resources of the
+// containers, of the Deployment u to values under deployments.<name>.
+func (c *Chart) templateDeployment(u map[string]interface{}, name string) {
+	key := valueKey(parameterRE.ReplaceAllString(name, ""))
+	if key == "" {
+		key = "deployment"
+	}
+
+	values := map[string]interface{}{}
+	spec, _ := u["spec"].(map[string]interface{})
+
+	if n, ok := spec["replicas"].(float64); ok {
+		values["replicas"] = int64(n)
+		spec["replicas"] = c.token(fmt.Sprintf("{{ %s }}", valueRef("deployments", key, "replicas")), false)
+	}
+
+	template, _ := spec["template"].(map[string]interface{})
+	podSpec, _ := template["spec"].(map[string]interface{})
+	containers, _ := podSpec["containers"].([]interface{})
+	containerValues := map[string]interface{}{}
+
+	for _, cv := range containers {
+		container, _ := cv.(map[string]interface{})
+		cname, _ := container["name"].(string)
+		ckey := valueKey(cname)
+
+		if ckey == "" {
+			continue
+		}
+
+		v := map[string]interface{}{}
+
+		if image, ok := container["image"].(string); ok && !strings.Contains(image, "${") {
+			repository, tag := splitImage(image)
+			v["image"] = map[string]interface{}{
+				"repository": repository,
+				"tag":        tag,
+			}
+			ref := valueRef("deployments", key, "containers", ckey, "image")
+			c.images[image] = fmt.Sprintf(`"{{ %s.repository }}{{ with %s.tag }}:{{ . }}{{ end }}"`, ref, ref)
+			container["image"] = c.token(c.images[image], false)
+		}
+
+		if resources, ok := container["resources"].(map[string]interface{}); ok && !hasParameter(resources) {
+			v["resources"] = resources
+			container["resources"] = c.token("toYaml "+valueRef("deployments", key, "containers", ckey, "resources"), true)
+		}
+
+		if len(v) != 0 {
+			containerValues[ckey] = v
+		}
+	}
+
+	if len(containerValues) != 0 {
+		values["containers"] = containerValues
+	}
+
+	if len(values) != 0 {
+		c.deployments[key] = values
+	}
+}
+
+// templateJob makes the containers of the Job u, such as a hook Job, use the
+// values of the images of the Deployments added before it.
+func (c *Chart) templateJob(u map[string]interface{}) {
+	spec, _ := u["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	podSpec, _ := template["spec"].(map[string]interface{})
+	containers, _ := podSpec["containers"].([]interface{})
+
+	for _, cv := range containers {
+		container, _ := cv.(map[string]interface{})
+
+		if image, ok := container["image"].(string); ok && c.images[image] != "" {
+			container["image"] = c.token(c.images[image], false)
+		}
+	}
+}
+
+// replace replaces the strings in v referencing parameters of the chart with
+// tokens for the matching template actions.
+func (c *Chart) replace(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, mv := range t {
+			t[k] = c.replace(mv)
+		}
+	case []interface{}:
+		for i, lv := range t {
+			t[i] = c.replace(lv)
+		}
+	case string:
+		return c.replaceString(t)
+	}
+
+	return v
+}
+
+// replaceString follows the template semantics: a whole ${NAME} value is a
+// string, a whole ${{NAME}} value is inserted as is, and references inside a
+// longer string are substituted into it. References to parameters the chart
+// does not have are left alone.
+func (c *Chart) replaceString(s string) interface{} {
+	if m := parameterRE.FindStringSubmatch(s); m != nil && m[0] == s {
+		ref, ok := c.parameterRef(m[1] + m[2])
+		switch {
+		case !ok:
+			return c.escape(s)
+		case m[1] != "":
+			return c.token(fmt.Sprintf("{{ %s }}", ref), false)
+		default:
+			return c.token(fmt.Sprintf("{{ %s | quote }}", ref), false)
+		}
+	}
+
+	matches := parameterRE.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return c.escape(s)
+	}
+
+	var (
+		b        strings.Builder
+		last     int
+		replaced bool
+	)
+
+	b.WriteString(`"`)
+
+	for _, m := range matches {
+		var name string
+
+		if m[2] >= 0 {
+			name = s[m[2]:m[3]]
+		} else {
+			name = s[m[4]:m[5]]
+		}
+
+		ref, ok := c.parameterRef(name)
+		if !ok {
+			continue
+		}
+
+		b.WriteString(quoteLiteral(s[last:m[0]]))
+		fmt.Fprintf(&b, "{{ %s }}", ref)
+
+		last = m[1]
+		replaced = true
+	}
+
+	if !replaced {
+		return c.escape(s)
+	}
+
+	b.WriteString(quoteLiteral(s[last:]))
+	b.WriteString(`"`)
+
+	return c.token(b.String(), false)
+}
+
+// escape returns s, or a token for it as a literal if it looks like a template
+// action.
+func (c *Chart) escape(s string) interface{} {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+
+	return c.token(`"`+quoteLiteral(s)+`"`, false)
+}
+
+// parameterRef returns the reference to the value of the parameter name, which
+// fails rendering when a required parameter has no value.
+func (c *Chart) parameterRef(name string) (string, bool) {
+	for _, p := range c.parameters {
+		if p.Name != name {
+			continue
+		}
+
+		ref := valueRef(valueKey(name))
+
+		if p.Required && p.Value == "" {
+			ref = fmt.Sprintf("(required %q %s)", name+" is required", ref)
+		}
+
+		return ref, true
+	}
+
+	return "", false
+}
+
+func (c *Chart) token(text string, block bool) string {
+	c.exprs = append(c.exprs, expr{text: text, block: block})
+
+	return fmt.Sprintf("__helm_%d__", len(c.exprs)-1)
+}
+
+// expand replaces the tokens in the marshalled object data with their template
+// actions, indenting block actions under their key.
+func (c *Chart) expand(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+
+	for i, line := range lines {
+		lines[i] = tokenRE.ReplaceAllStringFunc(line, func(token string) string {
+			n, _ := strconv.Atoi(tokenRE.FindStringSubmatch(token)[1])
+			e := c.exprs[n]
+
+			if !e.block {
+				return e.text
+			}
+
+			indent := len(line) - len(strings.TrimLeft(line, " -"))
+
+			return fmt.Sprintf("{{- %s | nindent %d }}", e.text, indent+2)
+		})
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// valuesYAML returns the values of the chart, the template parameters first
+// with their descriptions as comments, then the values of the Deployments.
+func (c *Chart) valuesYAML() ([]byte, error) {
+	root := &yamlv3.Node{Kind: yamlv3.MappingNode}
+
+	for _, p := range c.parameters {
+		if len(c.deployments) != 0 && valueKey(p.Name) == "deployments" {
+			return nil, fmt.Errorf("parameter %s conflicts with the values of the deployments", p.Name)
+		}
+
+		key := &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: valueKey(p.Name)}
+		value := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: p.Value}
+
+		var comments []string
+
+		if p.Description != "" {
+			comments = append(comments, p.Description)
+		}
+
+		if p.Generate != "" {
+			comments = append(comments, fmt.Sprintf("OpenShift generated this value from %q. Set it before installing.", p.From))
+		}
+
+		if p.Required {
+			comments = append(comments, "Required.")
+		}
+
+		key.HeadComment = strings.Join(comments, "\n")
+		root.Content = append(root.Content, key, value)
+	}
+
+	if len(c.deployments) != 0 {
+		value := &yamlv3.Node{}
+		if err := value.Encode(c.deployments); err != nil {
+			return nil, err
+		}
+
+		root.Content = append(root.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: "deployments"}, value)
+	}
+
+	if len(root.Content) == 0 {
+		return []byte("{}\n"), nil
+	}
+
+	var b bytes.Buffer
+
+	e := yamlv3.NewEncoder(&b)
+	e.SetIndent(2)
+
+	if err := e.Encode(root); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// fileName returns a unique name for the template of the object kind name.
+func (c *Chart) fileName(kind, name string) string {
+	base := strings.Trim(fileNameRE.ReplaceAllString(strings.ToLower(
+		parameterRE.ReplaceAllString(name, "$1$2")+"-"+kind), "-"), "-")
+
+	file := base + ".yaml"
+
+	for i := 2; c.templates[file] != nil; i++ {
+		file = fmt.Sprintf("%s-%d.yaml", base, i)
+	}
+
+	return file
+}
+
+// valueKey returns the lower camel case form of a parameter or object name,
+// such as memoryLimit for MEMORY_LIMIT or myApp for my-app.
+func valueKey(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+
+	for i, w := range words {
+		if strings.ToUpper(w) == w {
+			w = strings.ToLower(w)
+		}
+
+		if i == 0 {
+			words[i] = strings.ToLower(w[:1]) + w[1:]
+		} else {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+
+	return strings.Join(words, "")
+}
+
+// valueRef returns the reference to the value at path.
+func valueRef(path ...string) string {
+	ref := ".Values"
+
+	for _, p := range path {
+		if identRE.MatchString(p) {
+			ref += "." + p
+		} else {
+			ref = fmt.Sprintf("(index %s %q)", ref, p)
+		}
+	}
+
+	return ref
+}
+
+// quoteLiteral escapes s for a YAML double quoted string in a template.
+func quoteLiteral(s string) string {
+	q := strconv.Quote(s)
+
+	return strings.ReplaceAll(q[1:len(q)-1], "{{", `{{ "{{" }}`)
+}
+
+// splitImage splits image into the repository and the tag, leaving images
+// pinned by digest whole.
+func splitImage(image string) (string, string) {
+	if strings.Contains(image, "@") {
+		return image, ""
+	}
+
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+
+	return image, ""
+}
+
+func hasParameter(v interface{}) bool {
+	data, err := json.Marshal(v)
+
+	return err != nil || parameterRE.Match(data)
+}
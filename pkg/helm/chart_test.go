@@ -0,0 +1,96 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	templatev1 "github.com/openshift/api/template/v1"
+)
+
+func TestChartValues(t *testing.T) {
+	deploy := func() map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "app"},
+			"spec": map[string]interface{}{
+				"replicas": 2,
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "app", "image": "quay.io/app:${APP}"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		parameters []templatev1.Parameter
+		values     string
+		err        bool
+	}{
+		{
+			name:       "parameter named like the deployment",
+			parameters: []templatev1.Parameter{{Name: "APP", Value: "1"}},
+			values:     "app: \"1\"\ndeployments:\n  app:\n    replicas: 2\n",
+		},
+		{
+			name:       "parameter named deployments",
+			parameters: []templatev1.Parameter{{Name: "APP"}, {Name: "DEPLOYMENTS"}},
+			err:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChart("app")
+			c.SetTemplate(&templatev1.Template{Parameters: tt.parameters})
+
+			if err := c.Add(deploy()); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			values, err := c.valuesYAML()
+			if (err != nil) != tt.err {
+				t.Fatalf("valuesYAML() error = %v, want error %v", err, tt.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if string(values) != tt.values {
+				t.Errorf("valuesYAML() = %q, want %q", values, tt.values)
+			}
+
+			if data := string(c.templates["app-deployment.yaml"]); !strings.Contains(data, "replicas: {{ .Values.deployments.app.replicas }}") {
+				t.Errorf("template does not use the deployment values:\n%s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package helm
+
+import (
+	"fmt"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	HookAnnotation             = "helm.sh/hook"
+	HookWeightAnnotation       = "helm.sh/hook-weight"
+	HookDeletePolicyAnnotation = "helm.sh/hook-delete-policy"
+)
+
+// HookJobs returns Jobs running the execNewPod lifecycle hooks of dc as Helm
+// hooks. Helm runs hooks before or after the whole release, so mid hooks run
+// right after the pre hooks, and it cannot ignore a failed hook, so hooks with
+// the Ignore policy fail the release like Abort. Hooks tagging images have no
+// equivalent and are left out.
+func HookJobs(dc *ocappsv1.DeploymentConfig) ([]*batchv1.Job, error) {
+	var pre, mid, post *ocappsv1.LifecycleHook
+
+	if p := dc.Spec.Strategy.RecreateParams; p != nil {
+		pre, mid, post = p.Pre, p.Mid, p.Post
+	}
+
+	if p := dc.Spec.Strategy.RollingParams; p != nil {
+		pre, post = p.Pre, p.Post
+	}
+
+	var jobs []*batchv1.Job
+
+	for _, h := range []struct {
+		name   string
+		hook   *ocappsv1.LifecycleHook
+		events string
+		weight string
+	}{
+		{"pre", pre, "pre-install,pre-upgrade", "-1"},
+		{"mid", mid, "pre-install,pre-upgrade", "0"},
+		{"post", post, "post-install,post-upgrade", "0"},
+	} {
+		if h.hook == nil || h.hook.ExecNewPod == nil {
+			continue
+		}
+
+		job, err := hookJob(dc, h.name, h.hook)
+		if err != nil {
+			return nil, err
+		}
+
+		job.Annotations = map[string]string{
+			HookAnnotation:             h.events,
+			HookWeightAnnotation:       h.weight,
+			HookDeletePolicyAnnotation: "before-hook-creation",
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// hookJob returns a Job running the command of hook in a copy of its
+// container, with only the volumes the hook asks for and their mounts.
+func hookJob(dc *ocappsv1.DeploymentConfig, name string, hook *ocappsv1.LifecycleHook) (*batchv1.Job, error) {
+	exec := hook.ExecNewPod
+
+	if dc.Spec.Template == nil {
+		return nil, fmt.Errorf("unable to build %s hook: dc has no pod template", name)
+	}
+
+	var container *corev1.Container
+
+	for i := range dc.Spec.Template.Spec.Containers {
+		if dc.Spec.Template.Spec.Containers[i].Name == exec.ContainerName {
+			container = dc.Spec.Template.Spec.Containers[i].DeepCopy()
+		}
+	}
+
+	if container == nil {
+		return nil, fmt.Errorf("unable to build %s hook: container %s not found", name, exec.ContainerName)
+	}
+
+	container.Command = exec.Command
+	container.Args = nil
+	container.Env = append(container.Env, exec.Env...)
+	container.Ports = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Lifecycle = nil
+
+	mounts := container.VolumeMounts
+	container.VolumeMounts = nil
+
+	for _, m := range mounts {
+		if hasName(exec.Volumes, m.Name) {
+			container.VolumeMounts = append(container.VolumeMounts, m)
+		}
+	}
+
+	spec := dc.Spec.Template.Spec.DeepCopy()
+	spec.Containers = []corev1.Container{*container}
+	spec.InitContainers = nil
+	spec.RestartPolicy = corev1.RestartPolicyNever
+	spec.Volumes = nil
+
+	for _, v := range dc.Spec.Template.Spec.Volumes {
+		if hasName(exec.Volumes, v.Name) {
+			spec.Volumes = append(spec.Volumes, v)
+		}
+	}
+
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-hook-%s", dc.Name, name),
+			Namespace: dc.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{Spec: *spec},
+		},
+	}
+
+	if hook.FailurePolicy != ocappsv1.LifecycleHookFailurePolicyRetry {
+		backoffLimit := int32(0)
+		job.Spec.BackoffLimit = &backoffLimit
+	}
+
+	return job, nil
+}
+
+func hasName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,196 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package helm
+
+import (
+	"reflect"
+	"testing"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func hookDC(strategy ocappsv1.DeploymentStrategy) *ocappsv1.DeploymentConfig {
+	return &ocappsv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+		Spec: ocappsv1.DeploymentConfigSpec{
+			Strategy: strategy,
+			Template: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "sidecar", Image: "quay.io/proxy:1"},
+						{
+							Name:           "app",
+							Image:          "quay.io/app:1",
+							Args:           []string{"serve"},
+							Env:            []corev1.EnvVar{{Name: "A", Value: "1"}},
+							Ports:          []corev1.ContainerPort{{ContainerPort: 8080}},
+							ReadinessProbe: &corev1.Probe{},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "data", MountPath: "/data"},
+								{Name: "cache", MountPath: "/cache"},
+							},
+						},
+					},
+					InitContainers: []corev1.Container{{Name: "init", Image: "quay.io/init:1"}},
+					Volumes: []corev1.Volume{
+						{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+						{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func execHook(policy ocappsv1.LifecycleHookFailurePolicy, volumes ...string) *ocappsv1.LifecycleHook {
+	return &ocappsv1.LifecycleHook{
+		FailurePolicy: policy,
+		ExecNewPod: &ocappsv1.ExecNewPodHook{
+			ContainerName: "app",
+			Command:       []string{"/bin/migrate"},
+			Env:           []corev1.EnvVar{{Name: "B", Value: "2"}},
+			Volumes:       volumes,
+		},
+	}
+}
+
+func TestHookJobs(t *testing.T) {
+	type job struct {
+		name         string
+		events       string
+		weight       string
+		backoffLimit *int32
+		mounts       []string
+		volumes      []string
+	}
+
+	zero := int32(0)
+
+	tests := []struct {
+		name     string
+		strategy ocappsv1.DeploymentStrategy
+		want     []job
+		err      bool
+	}{
+		{
+			name: "recreate",
+			strategy: ocappsv1.DeploymentStrategy{
+				Type: ocappsv1.DeploymentStrategyTypeRecreate,
+				RecreateParams: &ocappsv1.RecreateDeploymentStrategyParams{
+					Pre:  execHook(ocappsv1.LifecycleHookFailurePolicyAbort, "data"),
+					Mid:  execHook(ocappsv1.LifecycleHookFailurePolicyRetry),
+					Post: execHook(ocappsv1.LifecycleHookFailurePolicyIgnore, "data", "cache"),
+				},
+			},
+			want: []job{
+				{"app-hook-pre", "pre-install,pre-upgrade", "-1", &zero, []string{"data"}, []string{"data"}},
+				{"app-hook-mid", "pre-install,pre-upgrade", "0", nil, nil, nil},
+				{"app-hook-post", "post-install,post-upgrade", "0", &zero, []string{"data", "cache"}, []string{"data", "cache"}},
+			},
+		},
+		{
+			name: "rolling",
+			strategy: ocappsv1.DeploymentStrategy{
+				Type: ocappsv1.DeploymentStrategyTypeRolling,
+				RollingParams: &ocappsv1.RollingDeploymentStrategyParams{
+					Post: execHook(ocappsv1.LifecycleHookFailurePolicyAbort, "cache", "missing"),
+				},
+			},
+			want: []job{
+				{"app-hook-post", "post-install,post-upgrade", "0", &zero, []string{"cache"}, []string{"cache"}},
+			},
+		},
+		{
+			name: "tag images only",
+			strategy: ocappsv1.DeploymentStrategy{
+				Type: ocappsv1.DeploymentStrategyTypeRecreate,
+				RecreateParams: &ocappsv1.RecreateDeploymentStrategyParams{
+					Pre: &ocappsv1.LifecycleHook{TagImages: []ocappsv1.TagImageHook{{ContainerName: "app"}}},
+				},
+			},
+		},
+		{
+			name: "unknown container",
+			strategy: ocappsv1.DeploymentStrategy{
+				Type: ocappsv1.DeploymentStrategyTypeRecreate,
+				RecreateParams: &ocappsv1.RecreateDeploymentStrategyParams{
+					Pre: &ocappsv1.LifecycleHook{ExecNewPod: &ocappsv1.ExecNewPodHook{ContainerName: "other"}},
+				},
+			},
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := HookJobs(hookDC(tt.strategy))
+			if (err != nil) != tt.err {
+				t.Fatalf("HookJobs() error = %v, want error %v", err, tt.err)
+			}
+
+			if len(jobs) != len(tt.want) {
+				t.Fatalf("HookJobs() returned %d jobs, want %d", len(jobs), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				j := jobs[i]
+				spec := j.Spec.Template.Spec
+
+				var mounts, volumes []string
+
+				for _, m := range spec.Containers[0].VolumeMounts {
+					mounts = append(mounts, m.Name)
+				}
+
+				for _, v := range spec.Volumes {
+					volumes = append(volumes, v.Name)
+				}
+
+				got := job{
+					name:         j.Name,
+					events:       j.Annotations[HookAnnotation],
+					weight:       j.Annotations[HookWeightAnnotation],
+					backoffLimit: j.Spec.BackoffLimit,
+					mounts:       mounts,
+					volumes:      volumes,
+				}
+
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("job %d = %+v, want %+v", i, got, want)
+				}
+
+				if len(spec.Containers) != 1 || len(spec.InitContainers) != 0 || spec.RestartPolicy != corev1.RestartPolicyNever {
+					t.Errorf("job %d pod spec = %+v, want the hook container only, never restarted", i, spec)
+				}
+
+				c := spec.Containers[0]
+				if c.Name != "app" || c.Image != "quay.io/app:1" || !reflect.DeepEqual(c.Command, []string{"/bin/migrate"}) ||
+					c.Args != nil || c.Ports != nil || c.ReadinessProbe != nil || len(c.Env) != 2 {
+					t.Errorf("job %d container = %+v, want the hook command in a copy of app", i, c)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,431 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const secretKind = "Secret"
+
+var (
+	roleresource        = rbacv1.SchemeGroupVersion.WithResource("roles")
+	rolebindingresource = rbacv1.SchemeGroupVersion.WithResource("rolebindings")
+	serviceresource     = corev1.SchemeGroupVersion.WithResource("services")
+	hparesource         = autoscalingv1.SchemeGroupVersion.WithResource("horizontalpodautoscalers")
+)
+
+var (
+	// stripMetadata are the metadata fields set by the cluster an object was
+	// read from.
+	stripMetadata = []string{
+		"uid", "resourceVersion", "generation", "creationTimestamp", "selfLink",
+		"managedFields", "ownerReferences", "deletionTimestamp", "deletionGracePeriodSeconds",
+	}
+	// stripAnnotationPrefixes are annotations set by controllers of the cluster
+	// an object was read from.
+	stripAnnotationPrefixes = []string{
+		convert.LastAppliedAnnotationKey,
+		"pv.kubernetes.io/",
+		"volume.kubernetes.io/",
+		"volume.beta.kubernetes.io/",
+		"kubernetes.io/service-account.",
+		"openshift.io/token-secret.",
+		"openshift.io/image.dockerRepositoryCheck",
+	}
+)
+
+// Dependencies fetches the objects the pod template of dc references, the
+// Roles and RoleBindings of its ServiceAccount, the ImageStreams its image
+// triggers follow and the Services and HorizontalPodAutoscalers that target
+// it, rewritten to target the Deployment instead. They are stripped of
+// cluster-specific metadata and moved to namespace, ready to be created on
+// another cluster. Secrets are only included with secrets and are reported
+// either way. Missing objects are skipped, as Preflight reports them.
+func (cl *Cluster) Dependencies(dc *ocappsv1.DeploymentConfig, namespace string, secrets bool) ([]*unstructured.Unstructured, error) {
+	var result []*unstructured.Unstructured
+
+	seen := map[string]bool{}
+
+	add := func(gvr schema.GroupVersionResource, kind, name string) error {
+		key := kind + "/" + name
+		if seen[key] {
+			return nil
+		}
+
+		seen[key] = true
+
+		u, err := cl.Client.Resource(gvr).Namespace(dc.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+
+		switch {
+		case apierrors.IsNotFound(err):
+			writer.WriteErr(1, "skipped %s: not found", key)
+			return nil
+		case err != nil:
+			return fmt.Errorf("unable to load %s: %w", key, err)
+		case generated(u):
+			writer.WriteOut(2, "skipped %s: generated by the cluster", key)
+			return nil
+		}
+
+		strip(u, namespace)
+		result = append(result, u)
+
+		return nil
+	}
+
+	if dc.Spec.Template != nil {
+		for _, ref := range convert.References("spec.template.spec", &dc.Spec.Template.Spec) {
+			if ref.Kind == secretKind && !secrets {
+				if !seen[ref.Kind+"/"+ref.Name] {
+					writer.WriteErr(0, "skipped Secret %s: use --include-secrets to bundle it", ref.Name)
+				}
+
+				seen[ref.Kind+"/"+ref.Name] = true
+
+				continue
+			}
+
+			if err := add(referenceresources[ref.Kind], ref.Kind, ref.Name); err != nil {
+				return nil, err
+			}
+		}
+
+		if sa := dc.Spec.Template.Spec.ServiceAccountName; sa != "" {
+			rbac, err := cl.serviceAccountRBAC(dc.Namespace, sa, namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, rbac...)
+		}
+	}
+
+	for _, t := range dc.Spec.Triggers {
+		if t.Type != ocappsv1.DeploymentTriggerOnImageChange || t.ImageChangeParams == nil {
+			continue
+		}
+
+		from := t.ImageChangeParams.From
+		if from.Kind != "ImageStreamTag" || (from.Namespace != "" && from.Namespace != dc.Namespace) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(from.Name, ":")
+
+		if err := add(imagestreamresource, "ImageStream", name); err != nil {
+			return nil, err
+		}
+	}
+
+	related, err := cl.relatedObjects(dc, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	result = append(result, related...)
+
+	for _, u := range result {
+		if u.GetKind() == secretKind {
+			writer.WriteErr(0, "bundled Secret %s: the output contains secret data", u.GetName())
+		}
+	}
+
+	return result, nil
+}
+
+// serviceAccountRBAC returns the RoleBindings in namespace from with sa as a
+// subject, and the Roles they bind, moved to namespace to.
+func (cl *Cluster) serviceAccountRBAC(from, sa, to string) ([]*unstructured.Unstructured, error) {
+	list, err := cl.Client.Resource(rolebindingresource).Namespace(from).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list rolebindings: %w", err)
+	}
+
+	var result []*unstructured.Unstructured
+
+	roles := map[string]bool{}
+
+	for i := range list.Items {
+		var rb rbacv1.RoleBinding
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].UnstructuredContent(), &rb); err != nil {
+			return nil, fmt.Errorf("unable to parse rolebinding %s: %w", list.Items[i].GetName(), err)
+		}
+
+		bound := false
+
+		for j, s := range rb.Subjects {
+			if s.Kind == rbacv1.ServiceAccountKind && s.Name == sa && (s.Namespace == "" || s.Namespace == from) {
+				rb.Subjects[j].Namespace = to
+				bound = true
+			}
+		}
+
+		if !bound {
+			continue
+		}
+
+		if rb.RoleRef.Kind == "Role" && !roles[rb.RoleRef.Name] {
+			roles[rb.RoleRef.Name] = true
+
+			role, err := cl.Client.Resource(roleresource).Namespace(from).Get(context.TODO(), rb.RoleRef.Name, metav1.GetOptions{})
+
+			switch {
+			case err == nil:
+				strip(role, to)
+				result = append(result, role)
+			case !apierrors.IsNotFound(err):
+				return nil, fmt.Errorf("unable to load Role/%s: %w", rb.RoleRef.Name, err)
+			}
+		}
+
+		m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rb)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert rolebinding %s: %w", rb.Name, err)
+		}
+
+		u := &unstructured.Unstructured{Object: m}
+		u.SetAPIVersion(rbacv1.SchemeGroupVersion.String())
+		u.SetKind("RoleBinding")
+		strip(u, to)
+		result = append(result, u)
+	}
+
+	return result, nil
+}
+
+// relatedObjects returns the Services selecting the pods of dc and the
+// HorizontalPodAutoscalers scaling dc, rewritten for the Deployment dc is
+// converted to and moved to namespace.
+func (cl *Cluster) relatedObjects(dc *ocappsv1.DeploymentConfig, namespace string) ([]*unstructured.Unstructured, error) {
+	var result []*unstructured.Unstructured
+
+	if dc.Spec.Template != nil {
+		services, err := cl.Client.Resource(serviceresource).Namespace(dc.Namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list services: %w", err)
+		}
+
+		for i := range services.Items {
+			u := &services.Items[i]
+
+			ok, err := RewriteService(u, dc)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				continue
+			}
+
+			// The cluster IP is allocated again by the target cluster.
+			unstructured.RemoveNestedField(u.Object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(u.Object, "spec", "clusterIPs")
+
+			strip(u, namespace)
+			result = append(result, u)
+		}
+	}
+
+	hpas, err := cl.Client.Resource(hparesource).Namespace(dc.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list horizontalpodautoscalers: %w", err)
+	}
+
+	for i := range hpas.Items {
+		u := &hpas.Items[i]
+
+		ok, err := RewriteHPA(u, dc)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		strip(u, namespace)
+		result = append(result, u)
+	}
+
+	return result, nil
+}
+
+// RewriteService replaces the DeploymentConfig labels in the selector of the
+// Service u with the Deployment ones if it selects the pods of dc, and returns
+// whether it does.
+func RewriteService(u *unstructured.Unstructured, dc *ocappsv1.DeploymentConfig) (bool, error) {
+	if dc.Spec.Template == nil {
+		return false, nil
+	}
+
+	selector, _, _ := unstructured.NestedStringMap(u.Object, "spec", "selector")
+	if len(selector) == 0 || !selects(selector, dc.Spec.Template.Labels) {
+		return false, nil
+	}
+
+	for from, to := range convert.ReplaceLabels {
+		if v, ok := selector[from]; ok {
+			selector[to] = v
+			delete(selector, from)
+		}
+	}
+
+	if err := unstructured.SetNestedStringMap(u.Object, selector, "spec", "selector"); err != nil {
+		return false, fmt.Errorf("unable to rewrite service %s: %w", u.GetName(), err)
+	}
+
+	return true, nil
+}
+
+// RewriteHPA points the HorizontalPodAutoscaler u at the Deployment converted
+// from dc if it scales dc, and returns whether it does.
+func RewriteHPA(u *unstructured.Unstructured, dc *ocappsv1.DeploymentConfig) (bool, error) {
+	kind, _, _ := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "kind")
+	name, _, _ := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "name")
+
+	if kind != "DeploymentConfig" || name != dc.Name {
+		return false, nil
+	}
+
+	ref := map[string]interface{}{
+		"apiVersion": appsv1.SchemeGroupVersion.String(),
+		"kind":       "Deployment",
+		"name":       dc.Name,
+	}
+
+	if err := unstructured.SetNestedMap(u.Object, ref, "spec", "scaleTargetRef"); err != nil {
+		return false, fmt.Errorf("unable to rewrite horizontalpodautoscaler %s: %w", u.GetName(), err)
+	}
+
+	return true, nil
+}
+
+func selects(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// generated reports whether u is created by the cluster itself, such as a
+// ServiceAccount token or the registry pull secret OpenShift adds for it.
+func generated(u *unstructured.Unstructured) bool {
+	if u.GetKind() != secretKind {
+		return false
+	}
+
+	t, _, _ := unstructured.NestedString(u.Object, "type")
+
+	return t == string(corev1.SecretTypeServiceAccountToken) ||
+		u.GetAnnotations()[corev1.ServiceAccountNameKey] != ""
+}
+
+// strip removes the status and cluster-specific metadata of u and moves it to
+// namespace.
+func strip(u *unstructured.Unstructured, namespace string) {
+	for _, f := range stripMetadata {
+		unstructured.RemoveNestedField(u.Object, "metadata", f)
+	}
+
+	unstructured.RemoveNestedField(u.Object, "status")
+
+	if a := u.GetAnnotations(); a != nil {
+		for k := range a {
+			for _, p := range stripAnnotationPrefixes {
+				if strings.HasPrefix(k, p) {
+					delete(a, k)
+				}
+			}
+		}
+
+		if len(a) == 0 {
+			a = nil
+		}
+
+		u.SetAnnotations(a)
+	}
+
+	u.SetNamespace(namespace)
+
+	switch u.GetKind() {
+	case "ServiceAccount":
+		// Token and pull secrets are generated again by the target cluster.
+		prefixes := []string{u.GetName() + "-token-", u.GetName() + "-dockercfg-"}
+
+		for _, field := range []string{"secrets", "imagePullSecrets"} {
+			refs, _, _ := unstructured.NestedSlice(u.Object, field)
+
+			var kept []interface{}
+
+			for _, r := range refs {
+				if m, ok := r.(map[string]interface{}); ok {
+					name, _, _ := unstructured.NestedString(m, "name")
+					if hasPrefix(name, prefixes) {
+						continue
+					}
+				}
+
+				kept = append(kept, r)
+			}
+
+			if kept == nil {
+				unstructured.RemoveNestedField(u.Object, field)
+			} else {
+				_ = unstructured.SetNestedSlice(u.Object, kept, field)
+			}
+		}
+	case "PersistentVolumeClaim":
+		// The claim binds to a new volume on the target cluster.
+		unstructured.RemoveNestedField(u.Object, "spec", "volumeName")
+	}
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
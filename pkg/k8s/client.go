@@ -23,41 +23,66 @@ THE SOFTWARE.
 package k8s
 
 import (
-	"path/filepath"
-
 	"github.com/csfreak/dc2deploy/pkg/writer"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
+	"k8s.io/client-go/restmapper"
 )
 
-var (
-	Client dynamic.Interface
-)
+// FieldManager is the field manager dc2deploy applies objects as.
+const FieldManager = "dc2deploy"
 
-func Init(kubeconfig string) error {
-	if kubeconfig == "" {
-		kubeconfig = filepath.Join(homedir.HomeDir(), ".kube", "config")
-		writer.WriteOut(2, "set kubeconfig: %+v", kubeconfig)
-	}
+// Cluster holds the clients for one cluster. A migration reads the
+// DeploymentConfig from a source Cluster and writes to a target Cluster, which
+// may be the same.
+type Cluster struct {
+	Client    dynamic.Interface
+	Discovery discovery.DiscoveryInterface
+	Mapper    meta.RESTMapper
+	// Namespace is the namespace of the current context, or the --namespace
+	// override, and defaults to "default".
+	Namespace string
+}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+// NewCluster builds the clients from getter, which resolves KUBECONFIG,
+// --context, --server, credentials, impersonation and in-cluster config the
+// way kubectl does.
+func NewCluster(getter genericclioptions.RESTClientGetter) (*Cluster, error) {
+	config, err := getter.ToRESTConfig()
 	if err != nil {
 		writer.WriteOut(2, "error building client config: %v", err)
-		return err
+		return nil, err
 	}
 
 	writer.WriteOut(2, "built client config for host %s", config.Host)
 
+	ns, _, err := getter.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		writer.WriteOut(2, "error reading namespace: %v", err)
+		return nil, err
+	}
+
 	client, err := dynamic.NewForConfig(config)
 	if err != nil {
 		writer.WriteOut(2, "error building client: %v", err)
-		return err
+		return nil, err
 	}
 
-	writer.WriteOut(2, "built client")
+	disco, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		writer.WriteOut(2, "error building discovery client: %v", err)
+		return nil, err
+	}
 
-	Client = client
+	writer.WriteOut(2, "built client")
 
-	return nil
+	return &Cluster{
+		Client:    client,
+		Discovery: disco,
+		Mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disco)),
+		Namespace: ns,
+	}, nil
 }
@@ -32,6 +32,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 var (
@@ -42,12 +43,12 @@ var (
 	}
 )
 
-func LoadDC(name string, namespace string) (*ocappsv1.DeploymentConfig, error) {
+func (cl *Cluster) LoadDC(name string, namespace string) (*ocappsv1.DeploymentConfig, error) {
 	if namespace == "" {
 		namespace = apiv1.NamespaceDefault
 	}
 
-	resp, err := Client.Resource(dcresource).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	resp, err := cl.Client.Resource(dcresource).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		writer.WriteOut(2, "unable to load deploymentconfig: name %s, namespace %s", name, namespace)
 		return nil, fmt.Errorf("unable to load %s: %w", name, err)
@@ -65,3 +66,57 @@ func LoadDC(name string, namespace string) (*ocappsv1.DeploymentConfig, error) {
 
 	return &dc, nil
 }
+
+// ListDCs returns the DeploymentConfigs in namespace, or in every namespace
+// if it is empty.
+func (cl *Cluster) ListDCs(namespace string) ([]*ocappsv1.DeploymentConfig, error) {
+	list, err := cl.Client.Resource(dcresource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list deploymentconfigs: %w", err)
+	}
+
+	result := make([]*ocappsv1.DeploymentConfig, 0, len(list.Items))
+
+	for i := range list.Items {
+		var dc ocappsv1.DeploymentConfig
+
+		err = runtime.DefaultUnstructuredConverter.
+			FromUnstructured(list.Items[i].UnstructuredContent(), &dc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse deploymentconfig %s: %w", list.Items[i].GetName(), err)
+		}
+
+		result = append(result, &dc)
+	}
+
+	return result, nil
+}
+
+// PatchDC applies a JSON merge patch to the DeploymentConfig.
+func (cl *Cluster) PatchDC(name string, namespace string, patch []byte) (*ocappsv1.DeploymentConfig, error) {
+	resp, err := cl.Client.Resource(dcresource).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to patch %s: %w", name, err)
+	}
+
+	var dc ocappsv1.DeploymentConfig
+
+	err = runtime.DefaultUnstructuredConverter.
+		FromUnstructured(resp.UnstructuredContent(), &dc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse deploymentconfig %s: %w", name, err)
+	}
+
+	return &dc, nil
+}
+
+func (cl *Cluster) DeleteDC(name string, namespace string) error {
+	policy := metav1.DeletePropagationBackground
+
+	err := cl.Client.Resource(dcresource).Namespace(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{PropagationPolicy: &policy})
+	if err != nil {
+		return fmt.Errorf("unable to delete %s: %w", name, err)
+	}
+
+	return nil
+}
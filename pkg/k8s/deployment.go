@@ -0,0 +1,180 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+const progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+
+var (
+	ErrWaitTimeout              = errors.New("timed out waiting for rollout")
+	ErrProgressDeadlineExceeded = errors.New("rollout exceeded its progress deadline")
+)
+
+func (cl *Cluster) GetDeployment(name string, namespace string) (*appsv1.Deployment, error) {
+	resp, err := cl.Client.Resource(deploymentresource).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load deployment %s: %w", name, err)
+	}
+
+	var deploy appsv1.Deployment
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(resp.UnstructuredContent(), &deploy)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse deployment %s: %w", name, err)
+	}
+
+	return &deploy, nil
+}
+
+func (cl *Cluster) DeleteDeployment(name string, namespace string) error {
+	policy := metav1.DeletePropagationForeground
+
+	err := cl.Client.Resource(deploymentresource).Namespace(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{PropagationPolicy: &policy})
+	if err != nil {
+		return fmt.Errorf("unable to delete deployment %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// WaitForDeployment watches the Deployment until its rollout is complete,
+// reporting progress as it changes. It returns ErrWaitTimeout if timeout
+// passes first and ErrProgressDeadlineExceeded if the Deployment reports its
+// progress deadline was exceeded.
+func (cl *Cluster) WaitForDeployment(name string, namespace string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	r := cl.Client.Resource(deploymentresource).Namespace(namespace)
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return r.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return r.Watch(ctx, options)
+		},
+	}
+
+	var last string
+
+	_, err := watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, nil, func(e watch.Event) (bool, error) {
+		u, ok := e.Object.(*unstructured.Unstructured)
+		if !ok || u.GetName() != name {
+			return false, nil
+		}
+
+		if e.Type == watch.Deleted {
+			return false, fmt.Errorf("deployment %s was deleted", name)
+		}
+
+		var deploy appsv1.Deployment
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &deploy); err != nil {
+			return false, fmt.Errorf("unable to parse deployment %s: %w", name, err)
+		}
+
+		status := rolloutStatus(&deploy)
+		if status != last {
+			writer.WriteErr(0, "deployment %s: %s", name, status)
+			last = status
+		}
+
+		return rolloutComplete(&deploy)
+	})
+
+	switch {
+	case errors.Is(err, wait.ErrWaitTimeout):
+		return fmt.Errorf("deployment %s: %w after %s", name, ErrWaitTimeout, timeout)
+	case err != nil:
+		return fmt.Errorf("deployment %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// rolloutComplete reports whether every replica of deploy is updated and
+// available, failing if the progress deadline was exceeded.
+func rolloutComplete(deploy *appsv1.Deployment) (bool, error) {
+	if deploy.Status.ObservedGeneration < deploy.Generation {
+		return false, nil
+	}
+
+	for _, c := range deploy.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == progressDeadlineExceededReason {
+			return false, ErrProgressDeadlineExceeded
+		}
+	}
+
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+
+	return deploy.Status.UpdatedReplicas >= replicas &&
+		deploy.Status.Replicas == deploy.Status.UpdatedReplicas &&
+		deploy.Status.AvailableReplicas >= deploy.Status.UpdatedReplicas, nil
+}
+
+func rolloutStatus(deploy *appsv1.Deployment) string {
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+
+	status := fmt.Sprintf("%d/%d updated, %d/%d ready, %d/%d available",
+		deploy.Status.UpdatedReplicas, replicas,
+		deploy.Status.ReadyReplicas, replicas,
+		deploy.Status.AvailableReplicas, replicas)
+
+	for _, c := range deploy.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing || c.Type == appsv1.DeploymentAvailable {
+			status += fmt.Sprintf(", %s=%s", c.Type, c.Status)
+
+			if c.Reason != "" {
+				status += " (" + c.Reason + ")"
+			}
+		}
+	}
+
+	return status
+}
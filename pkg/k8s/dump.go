@@ -0,0 +1,240 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// listedresources are the resources dc2deploy lists, which must be known to
+// the dump client even when the dump has none of them.
+var listedresources = map[schema.GroupVersionResource]string{
+	dcresource:            "DeploymentConfigList",
+	resourcequotaresource: "ResourceQuotaList",
+	rolebindingresource:   "RoleBindingList",
+	serviceresource:       "ServiceList",
+	hparesource:           "HorizontalPodAutoscalerList",
+}
+
+// NewDumpCluster returns a Cluster serving the objects found in dir, an
+// `oc adm inspect` or must-gather directory, so DeploymentConfigs can be
+// scanned and converted without access to the cluster the dump was taken
+// from. Every YAML or JSON file in dir is read and files that are not
+// Kubernetes objects are skipped. The Namespace of the Cluster is the only
+// namespace in the dump, or "default".
+func NewDumpCluster(dir string) (*Cluster, error) {
+	objs, err := loadDump(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no kubernetes objects found in %s", dir)
+	}
+
+	lists := map[schema.GroupVersionResource]string{}
+	for gvr, kind := range listedresources {
+		lists[gvr] = kind
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	resources := map[schema.GroupVersion]*metav1.APIResourceList{}
+	namespaces := map[string]bool{}
+	seeded := make([]runtime.Object, 0, len(objs))
+
+	for _, u := range objs {
+		gvk := u.GroupVersionKind()
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		namespaced := u.GetNamespace() != ""
+
+		scope := meta.RESTScopeRoot
+		if namespaced {
+			scope = meta.RESTScopeNamespace
+		}
+
+		mapper.Add(gvk, scope)
+		lists[gvr] = gvk.Kind + "List"
+
+		rl, ok := resources[gvk.GroupVersion()]
+		if !ok {
+			rl = &metav1.APIResourceList{GroupVersion: gvk.GroupVersion().String()}
+			resources[gvk.GroupVersion()] = rl
+		}
+
+		if !hasResource(rl, gvr.Resource) {
+			rl.APIResources = append(rl.APIResources, metav1.APIResource{
+				Name:       gvr.Resource,
+				Kind:       gvk.Kind,
+				Namespaced: namespaced,
+			})
+		}
+
+		if namespaced {
+			namespaces[u.GetNamespace()] = true
+		}
+
+		seeded = append(seeded, u)
+	}
+
+	disco := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	for _, rl := range resources {
+		disco.Resources = append(disco.Resources, rl)
+	}
+
+	ns := corev1.NamespaceDefault
+
+	if len(namespaces) == 1 {
+		for n := range namespaces {
+			ns = n
+		}
+	}
+
+	writer.WriteOut(2, "loaded %d objects in %d namespaces from %s", len(seeded), len(namespaces), dir)
+
+	return &Cluster{
+		Client:    fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), lists, seeded...),
+		Discovery: disco,
+		Mapper:    mapper,
+		Namespace: ns,
+	}, nil
+}
+
+// loadDump reads the objects in the files under dir, flattening Lists and
+// dropping duplicates, which must-gather writes for some resources.
+func loadDump(dir string) ([]*unstructured.Unstructured, error) {
+	var result []*unstructured.Unstructured
+
+	seen := map[string]bool{}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		objs, err := loadDumpFile(path)
+		if err != nil {
+			writer.WriteOut(2, "skipped %s: %v", path, err)
+			return nil
+		}
+
+		for _, u := range objs {
+			key := u.GroupVersionKind().String() + "/" + u.GetNamespace() + "/" + u.GetName()
+			if u.GetName() == "" || seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			result = append(result, u)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", dir, err)
+	}
+
+	return result, nil
+}
+
+func loadDumpFile(path string) ([]*unstructured.Unstructured, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []*unstructured.Unstructured
+
+	d := yaml.NewYAMLOrJSONDecoder(f, 4096)
+
+	for {
+		u := &unstructured.Unstructured{}
+
+		err := d.Decode(&u.Object)
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if u.GetKind() == "" || u.GetAPIVersion() == "" {
+			continue
+		}
+
+		if !u.IsList() {
+			result = append(result, u)
+			continue
+		}
+
+		err = u.EachListItem(func(o runtime.Object) error {
+			if item, ok := o.(*unstructured.Unstructured); ok && item.GetKind() != "" {
+				result = append(result, item)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func hasResource(rl *metav1.APIResourceList, resource string) bool {
+	for _, r := range rl.APIResources {
+		if r.Name == resource {
+			return true
+		}
+	}
+
+	return false
+}
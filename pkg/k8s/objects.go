@@ -0,0 +1,257 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// ErrNotServed is returned for objects of a kind the cluster does not serve.
+var ErrNotServed = errors.New("not served by the cluster")
+
+// Create creates obj, or each item of obj if it is a List.
+func (cl *Cluster) Create(obj runtime.Object) error {
+	objs, err := ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range objs {
+		r, err := cl.resourceFor(u)
+		if err != nil {
+			return err
+		}
+
+		_, err = r.Create(context.TODO(), u, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("unable to create %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+
+		writer.WriteOut(1, "created %s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName())
+	}
+
+	return nil
+}
+
+// Exists reports whether the object u refers to exists.
+func (cl *Cluster) Exists(u *unstructured.Unstructured) (bool, error) {
+	r, err := cl.resourceFor(u)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = r.Get(context.TODO(), u.GetName(), metav1.GetOptions{})
+
+	switch {
+	case err == nil:
+		return true, nil
+	case apierrors.IsNotFound(err):
+		return false, nil
+	default:
+		return false, fmt.Errorf("unable to load %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+}
+
+// Get returns the object u refers to.
+func (cl *Cluster) Get(u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	r, err := cl.resourceFor(u)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := r.Get(context.TODO(), u.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+
+	return obj, nil
+}
+
+// Replace updates the object u refers to so it is u, or creates u if it does
+// not exist.
+func (cl *Cluster) Replace(u *unstructured.Unstructured) error {
+	r, err := cl.resourceFor(u)
+	if err != nil {
+		return err
+	}
+
+	current, err := r.Get(context.TODO(), u.GetName(), metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		u.SetResourceVersion("")
+
+		if _, err := r.Create(context.TODO(), u, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("unable to create %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+
+		return nil
+	case err != nil:
+		return fmt.Errorf("unable to load %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+
+	u.SetResourceVersion(current.GetResourceVersion())
+
+	if _, err := r.Update(context.TODO(), u, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+
+	return nil
+}
+
+// Delete deletes the object u refers to.
+func (cl *Cluster) Delete(u *unstructured.Unstructured) error {
+	r, err := cl.resourceFor(u)
+	if err != nil {
+		return err
+	}
+
+	policy := metav1.DeletePropagationForeground
+
+	err = r.Delete(context.TODO(), u.GetName(), metav1.DeleteOptions{PropagationPolicy: &policy})
+	if err != nil {
+		return fmt.Errorf("unable to delete %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+
+	return nil
+}
+
+// Patch applies a JSON merge patch to the object u refers to.
+func (cl *Cluster) Patch(u *unstructured.Unstructured, patch []byte) error {
+	r, err := cl.resourceFor(u)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Patch(context.TODO(), u.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to patch %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+
+	return nil
+}
+
+// ToUnstructured converts obj, or each item of obj if it is a List.
+func ToUnstructured(obj runtime.Object) ([]*unstructured.Unstructured, error) {
+	if l, ok := obj.(*corev1.List); ok {
+		var result []*unstructured.Unstructured
+
+		for _, item := range l.Items {
+			u := &unstructured.Unstructured{}
+
+			if err := json.Unmarshal(item.Raw, &u.Object); err != nil {
+				return nil, fmt.Errorf("unable to parse list item: %w", err)
+			}
+
+			result = append(result, u)
+		}
+
+		return result, nil
+	}
+
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert object: %w", err)
+	}
+
+	return []*unstructured.Unstructured{{Object: m}}, nil
+}
+
+func (cl *Cluster) resourceFor(u *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := u.GroupVersionKind()
+
+	mapping, err := cl.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("%s is %w", gvk.GroupKind().String(), ErrNotServed)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to find resource for %s: %w", gvk.String(), err)
+	}
+
+	if mapping.Scope.Name() == "namespace" {
+		return cl.Client.Resource(mapping.Resource).Namespace(u.GetNamespace()), nil
+	}
+
+	return cl.Client.Resource(mapping.Resource), nil
+}
+
+// Apply server-side applies obj, or each item of obj if it is a List, as
+// FieldManager and returns the objects the server responded with. With dryRun
+// the request is validated and admitted without being persisted.
+func (cl *Cluster) Apply(obj runtime.Object, force bool, dryRun bool) ([]*unstructured.Unstructured, error) {
+	objs, err := ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	}
+
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+
+	var result []*unstructured.Unstructured
+
+	for _, u := range objs {
+		r, err := cl.resourceFor(u)
+		if err != nil {
+			return nil, err
+		}
+
+		unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(u.Object, "status")
+
+		data, err := json.Marshal(u)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+
+		resp, err := r.Patch(context.TODO(), u.GetName(), types.ApplyPatchType, data, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to apply %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+
+		writer.WriteOut(1, "applied %s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName())
+
+		result = append(result, resp)
+	}
+
+	return result, nil
+}
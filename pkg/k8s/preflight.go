@@ -0,0 +1,346 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	deploymentresource = schema.GroupVersionResource{
+		Group:    "apps",
+		Version:  "v1",
+		Resource: "deployments",
+	}
+	imagestreamresource = schema.GroupVersionResource{
+		Group:    "image.openshift.io",
+		Version:  "v1",
+		Resource: "imagestreams",
+	}
+	resourcequotaresource = corev1.SchemeGroupVersion.WithResource("resourcequotas")
+	referenceresources    = map[string]schema.GroupVersionResource{
+		"ServiceAccount":        corev1.SchemeGroupVersion.WithResource("serviceaccounts"),
+		"Secret":                corev1.SchemeGroupVersion.WithResource("secrets"),
+		"ConfigMap":             corev1.SchemeGroupVersion.WithResource("configmaps"),
+		"PersistentVolumeClaim": corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims"),
+	}
+)
+
+var (
+	DeploymentExistsWarning = &convert.Warning{
+		Code:        "DeploymentExists",
+		Severity:    convert.BlockingSeverity,
+		Name:        "Preflight - Deployment Exists",
+		Path:        "metadata.name",
+		Description: "A Deployment with the same name already exists in the namespace.",
+	}
+	MissingImageStreamWarning = &convert.Warning{
+		Code:        "MissingImageStream",
+		Severity:    convert.WarnSeverity,
+		Name:        "Preflight - Missing ImageStream",
+		Path:        "spec.triggers[*].imageChangeParams.from",
+		Description: "The ImageStream or tag referenced by an image trigger does not exist.",
+	}
+	MissingReferenceWarning = &convert.Warning{
+		Code:        "MissingReference",
+		Severity:    convert.WarnSeverity,
+		Name:        "Preflight - Missing Reference",
+		Path:        "spec.template.spec",
+		Description: "An object referenced by the pod template does not exist.",
+	}
+	ImageTriggerUnsupportedWarning = &convert.Warning{
+		Code:        "ImageTriggerUnsupported",
+		Severity:    convert.WarnSeverity,
+		Name:        "Preflight - Image Triggers Unsupported",
+		Path:        "spec.triggers",
+		Description: "The cluster does not serve image.openshift.io, so the image trigger annotation will not be acted on.",
+	}
+	QuotaHeadroomWarning = &convert.Warning{
+		Code:        "QuotaHeadroom",
+		Severity:    convert.WarnSeverity,
+		Name:        "Preflight - ResourceQuota Headroom",
+		Path:        "spec.replicas",
+		Description: "The ResourceQuota does not leave room to run the Deployment pods alongside the DeploymentConfig pods during cutover.",
+	}
+	PreflightUnverifiedWarning = &convert.Warning{
+		Code:        "PreflightUnverified",
+		Severity:    convert.InfoSeverity,
+		Name:        "Preflight - Unverified",
+		Path:        "",
+		Description: "A preflight check could not be completed.",
+	}
+)
+
+// Preflight checks the cluster for conditions that would stop deploy, the
+// conversion of dc, from working once it is created. Objects are looked up in
+// the namespace of deploy, which may differ from that of dc. References to the
+// objects of bundled, which will be created along with deploy, are not
+// reported as missing.
+func (cl *Cluster) Preflight(dc *ocappsv1.DeploymentConfig, deploy *appsv1.Deployment, bundled []*unstructured.Unstructured) []*convert.Warning {
+	var result []*convert.Warning
+
+	obj := convert.ObjectReference{
+		Kind:      "DeploymentConfig",
+		Namespace: dc.Namespace,
+		Name:      dc.Name,
+	}
+
+	result = append(result, cl.checkDeploymentExists(obj, deploy)...)
+	result = append(result, cl.checkImageStreams(obj, dc, deploy.Namespace)...)
+	result = append(result, cl.checkReferences(obj, dc, deploy.Namespace, bundled)...)
+	result = append(result, cl.checkImageTriggers(obj, dc)...)
+	result = append(result, cl.checkQuota(obj, dc, deploy.Namespace)...)
+
+	return result
+}
+
+func (cl *Cluster) checkDeploymentExists(obj convert.ObjectReference, deploy *appsv1.Deployment) []*convert.Warning {
+	_, err := cl.Client.Resource(deploymentresource).Namespace(deploy.Namespace).Get(context.TODO(), deploy.Name, metav1.GetOptions{})
+
+	switch {
+	case err == nil:
+		return []*convert.Warning{DeploymentExistsWarning.At(obj, "metadata.name", deploy.Name)}
+	case apierrors.IsNotFound(err):
+		return nil
+	default:
+		return []*convert.Warning{unverified(obj, "metadata.name", err)}
+	}
+}
+
+func (cl *Cluster) checkImageStreams(obj convert.ObjectReference, dc *ocappsv1.DeploymentConfig, namespace string) []*convert.Warning {
+	var result []*convert.Warning
+
+	for i, t := range dc.Spec.Triggers {
+		if t.Type != ocappsv1.DeploymentTriggerOnImageChange || t.ImageChangeParams == nil {
+			continue
+		}
+
+		from := t.ImageChangeParams.From
+		path := fmt.Sprintf("spec.triggers[%d].imageChangeParams.from", i)
+
+		if from.Kind != "ImageStreamTag" {
+			continue
+		}
+
+		isnamespace := from.Namespace
+		if isnamespace == "" {
+			isnamespace = namespace
+		}
+
+		name, tag, _ := strings.Cut(from.Name, ":")
+
+		is, err := cl.Client.Resource(imagestreamresource).Namespace(isnamespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				result = append(result, MissingImageStreamWarning.At(obj, path, isnamespace+"/"+from.Name))
+			} else {
+				result = append(result, unverified(obj, path, err))
+			}
+
+			continue
+		}
+
+		if !hasImageStreamTag(is.UnstructuredContent(), tag) {
+			result = append(result, MissingImageStreamWarning.At(obj, path, isnamespace+"/"+from.Name))
+		}
+	}
+
+	return result
+}
+
+func hasImageStreamTag(is map[string]interface{}, tag string) bool {
+	for _, field := range [][]string{{"status", "tags"}, {"spec", "tags"}} {
+		tags, _, _ := unstructured.NestedSlice(is, field...)
+
+		for _, t := range tags {
+			if m, ok := t.(map[string]interface{}); ok {
+				if m["tag"] == tag || m["name"] == tag {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (cl *Cluster) checkReferences(obj convert.ObjectReference, dc *ocappsv1.DeploymentConfig, namespace string, bundled []*unstructured.Unstructured) []*convert.Warning {
+	var result []*convert.Warning
+
+	if dc.Spec.Template == nil {
+		return nil
+	}
+
+	created := map[string]bool{}
+	for _, u := range bundled {
+		created[u.GetKind()+"/"+u.GetName()] = true
+	}
+
+	for _, ref := range convert.References("spec.template.spec", &dc.Spec.Template.Spec) {
+		if created[ref.Kind+"/"+ref.Name] {
+			continue
+		}
+
+		_, err := cl.Client.Resource(referenceresources[ref.Kind]).Namespace(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+
+		switch {
+		case err == nil:
+			continue
+		case apierrors.IsNotFound(err):
+			w := MissingReferenceWarning.At(obj, ref.Path, ref.Kind+"/"+ref.Name)
+			if ref.Optional {
+				w.Severity = convert.InfoSeverity
+			}
+
+			result = append(result, w)
+		default:
+			result = append(result, unverified(obj, ref.Path, err))
+		}
+	}
+
+	return result
+}
+
+func (cl *Cluster) checkImageTriggers(obj convert.ObjectReference, dc *ocappsv1.DeploymentConfig) []*convert.Warning {
+	for i, t := range dc.Spec.Triggers {
+		if t.Type != ocappsv1.DeploymentTriggerOnImageChange {
+			continue
+		}
+
+		path := fmt.Sprintf("spec.triggers[%d]", i)
+
+		_, err := cl.Discovery.ServerResourcesForGroupVersion(imagestreamresource.GroupVersion().String())
+
+		switch {
+		case err == nil:
+			return nil
+		case apierrors.IsNotFound(err):
+			return []*convert.Warning{ImageTriggerUnsupportedWarning.At(obj, path, imagestreamresource.GroupVersion().String())}
+		default:
+			return []*convert.Warning{unverified(obj, path, err)}
+		}
+	}
+
+	return nil
+}
+
+// checkQuota checks that every ResourceQuota in the namespace has room for a
+// second full set of pods, as the DeploymentConfig and the Deployment both run
+// their replicas during cutover.
+func (cl *Cluster) checkQuota(obj convert.ObjectReference, dc *ocappsv1.DeploymentConfig, namespace string) []*convert.Warning {
+	if dc.Spec.Template == nil || dc.Spec.Replicas == 0 {
+		return nil
+	}
+
+	list, err := cl.Client.Resource(resourcequotaresource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return []*convert.Warning{unverified(obj, "spec.replicas", err)}
+	}
+
+	needed := podResources(&dc.Spec.Template.Spec, int64(dc.Spec.Replicas))
+
+	var result []*convert.Warning
+
+	for _, item := range list.Items {
+		var quota corev1.ResourceQuota
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.UnstructuredContent(), &quota); err != nil {
+			return []*convert.Warning{unverified(obj, "spec.replicas", err)}
+		}
+
+		for name, hard := range quota.Status.Hard {
+			want, ok := needed[name]
+			if !ok {
+				continue
+			}
+
+			free := hard.DeepCopy()
+			if used, ok := quota.Status.Used[name]; ok {
+				free.Sub(used)
+			}
+
+			if want.Cmp(free) > 0 {
+				writer.WriteOut(2, "quota %s: %s needs %s, %s free", quota.Name, name, want.String(), free.String())
+				result = append(result, QuotaHeadroomWarning.At(obj, "spec.replicas", fmt.Sprintf("%s %s: need %s, free %s", quota.Name, name, want.String(), free.String())))
+			}
+		}
+	}
+
+	return result
+}
+
+// podResources returns the quota usage of replicas pods of spec.
+func podResources(spec *corev1.PodSpec, replicas int64) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	limits := corev1.ResourceList{}
+
+	for _, c := range spec.Containers {
+		addResources(requests, c.Resources.Requests)
+		addResources(limits, c.Resources.Limits)
+	}
+
+	result := corev1.ResourceList{
+		corev1.ResourcePods: *resource.NewQuantity(replicas, resource.DecimalSI),
+	}
+
+	for name, q := range requests {
+		total := *resource.NewMilliQuantity(q.MilliValue()*replicas, q.Format)
+		result[name] = total
+		result[corev1.ResourceName("requests."+string(name))] = total
+	}
+
+	for name, q := range limits {
+		total := *resource.NewMilliQuantity(q.MilliValue()*replicas, q.Format)
+		result[corev1.ResourceName("limits."+string(name))] = total
+	}
+
+	return result
+}
+
+func addResources(total, add corev1.ResourceList) {
+	for name, q := range add {
+		sum := total[name]
+		sum.Add(q)
+		total[name] = sum
+	}
+}
+
+func unverified(obj convert.ObjectReference, path string, err error) *convert.Warning {
+	writer.WriteOut(2, "preflight check %s failed: %v", path, err)
+
+	return PreflightUnverifiedWarning.At(obj, path, err.Error())
+}
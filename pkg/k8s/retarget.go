@@ -0,0 +1,206 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Retargeted is a Service or HorizontalPodAutoscaler that Retarget pointed at
+// a Deployment, with the field it changed and its value before and after.
+type Retargeted struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Namespace  string                 `json:"namespace"`
+	Name       string                 `json:"name"`
+	Field      []string               `json:"field"`
+	From       map[string]interface{} `json:"from"`
+	To         map[string]interface{} `json:"to"`
+}
+
+// rewriteFunc rewrites an object that targets a DeploymentConfig to target its
+// Deployment, and returns whether it does.
+type rewriteFunc func(*unstructured.Unstructured, *ocappsv1.DeploymentConfig) (bool, error)
+
+// Targeting returns the Services in namespace selecting the pods of dc and
+// the HorizontalPodAutoscalers scaling dc, as they are.
+func (cl *Cluster) Targeting(dc *ocappsv1.DeploymentConfig, namespace string) ([]*unstructured.Unstructured, error) {
+	var result []*unstructured.Unstructured
+
+	services, err := cl.Client.Resource(serviceresource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list services: %w", err)
+	}
+
+	hpas, err := cl.Client.Resource(hparesource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list horizontalpodautoscalers: %w", err)
+	}
+
+	for _, l := range []struct {
+		items   []unstructured.Unstructured
+		rewrite rewriteFunc
+	}{
+		{services.Items, RewriteService},
+		{hpas.Items, RewriteHPA},
+	} {
+		for i := range l.items {
+			ok, err := l.rewrite(l.items[i].DeepCopy(), dc)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				result = append(result, &l.items[i])
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Retarget rewrites the Services in namespace selecting the pods of dc and the
+// HorizontalPodAutoscalers scaling dc to target the Deployment dc is converted
+// to, and returns what it changed so Restore can put it back.
+func (cl *Cluster) Retarget(dc *ocappsv1.DeploymentConfig, namespace string) ([]*Retargeted, error) {
+	objs, err := cl.Targeting(dc, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Retargeted
+
+	for _, u := range objs {
+		rewrite, field := RewriteService, []string{"spec", "selector"}
+		if u.GetKind() != "Service" {
+			rewrite, field = RewriteHPA, []string{"spec", "scaleTargetRef"}
+		}
+
+		r, err := cl.retarget(u, dc, rewrite, field...)
+		if err != nil {
+			return result, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// retarget rewrites u with rewrite and patches the field of u it changes.
+func (cl *Cluster) retarget(u *unstructured.Unstructured, dc *ocappsv1.DeploymentConfig, rewrite rewriteFunc, field ...string) (*Retargeted, error) {
+	from, _, _ := unstructured.NestedMap(u.Object, field...)
+
+	ok, err := rewrite(u, dc)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	to, _, _ := unstructured.NestedMap(u.Object, field...)
+
+	r := &Retargeted{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+		Field:      field,
+		From:       from,
+		To:         to,
+	}
+
+	if err := cl.patchField(r, from, to); err != nil {
+		return nil, err
+	}
+
+	writer.WriteErr(1, "retargeted %s %s/%s", r.Kind, r.Namespace, r.Name)
+
+	return r, nil
+}
+
+// Restore puts back the fields Retarget changed. Objects deleted since are
+// skipped.
+func (cl *Cluster) Restore(retargeted []*Retargeted) error {
+	for _, r := range retargeted {
+		err := cl.patchField(r, r.To, r.From)
+
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return err
+		}
+
+		writer.WriteErr(1, "restored %s %s/%s", r.Kind, r.Namespace, r.Name)
+	}
+
+	return nil
+}
+
+// patchField changes the field of the object r refers to from one value to
+// another with a JSON merge patch, removing the keys only the first one has.
+func (cl *Cluster) patchField(r *Retargeted, from, to map[string]interface{}) error {
+	value := map[string]interface{}{}
+
+	for k := range from {
+		value[k] = nil
+	}
+
+	for k, v := range to {
+		value[k] = v
+	}
+
+	patch := map[string]interface{}{}
+	m := patch
+
+	for i, f := range r.Field {
+		if i == len(r.Field)-1 {
+			m[f] = value
+			break
+		}
+
+		next := map[string]interface{}{}
+		m[f] = next
+		m = next
+	}
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("unable to marshal patch: %w", err)
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.FromAPIVersionAndKind(r.APIVersion, r.Kind))
+	u.SetNamespace(r.Namespace)
+	u.SetName(r.Name)
+
+	return cl.Patch(u, data)
+}
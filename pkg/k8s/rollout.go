@@ -0,0 +1,161 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+	"github.com/csfreak/dc2deploy/pkg/writer"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	rolloutPollInterval = 5 * time.Second
+
+	deploymentPhaseComplete = "Complete"
+	deploymentPhaseFailed   = "Failed"
+)
+
+var (
+	replicationcontrollerresource = corev1.SchemeGroupVersion.WithResource("replicationcontrollers")
+)
+
+var (
+	RolloutInProgressWarning = &convert.Warning{
+		Code:        "RolloutInProgress",
+		Severity:    convert.BlockingSeverity,
+		Name:        "Rollout - In Progress",
+		Path:        "status",
+		Description: "The latest rollout has not completed, so the pod template may not be what is running.",
+	}
+	RolloutFailedWarning = &convert.Warning{
+		Code:        "RolloutFailed",
+		Severity:    convert.BlockingSeverity,
+		Name:        "Rollout - Failed",
+		Path:        "status",
+		Description: "The latest rollout failed, so the pod template is not what is running.",
+	}
+	RolloutCancelledWarning = &convert.Warning{
+		Code:        "RolloutCancelled",
+		Severity:    convert.BlockingSeverity,
+		Name:        "Rollout - Cancelled",
+		Path:        "status",
+		Description: "The latest rollout was cancelled, so the pod template is not what is running.",
+	}
+)
+
+// CheckRollout returns blocking warnings if the latest rollout of dc is still
+// in progress, failed or was cancelled. It reads the status of dc and the
+// annotations of the ReplicationController of its latest version.
+func (cl *Cluster) CheckRollout(dc *ocappsv1.DeploymentConfig) []*convert.Warning {
+	obj := convert.ObjectReference{
+		Kind:      "DeploymentConfig",
+		Namespace: dc.Namespace,
+		Name:      dc.Name,
+	}
+
+	for _, c := range dc.Status.Conditions {
+		if c.Type != ocappsv1.DeploymentProgressing {
+			continue
+		}
+
+		switch ocappsv1.DeploymentConditionReason(c.Reason) {
+		case ocappsv1.ProgressDeadlineExceededReason:
+			return []*convert.Warning{RolloutFailedWarning.At(obj, "status.conditions", c.Reason)}
+		case ocappsv1.RolloutCancelledReason:
+			return []*convert.Warning{RolloutCancelledWarning.At(obj, "status.conditions", c.Reason)}
+		}
+	}
+
+	if dc.Status.LatestVersion != 0 {
+		rcname := fmt.Sprintf("%s-%d", dc.Name, dc.Status.LatestVersion)
+
+		rc, err := cl.Client.Resource(replicationcontrollerresource).Namespace(dc.Namespace).Get(context.TODO(), rcname, metav1.GetOptions{})
+
+		switch {
+		case apierrors.IsNotFound(err):
+			return []*convert.Warning{RolloutInProgressWarning.At(obj, "status.latestVersion", rcname)}
+		case err != nil:
+			return []*convert.Warning{unverified(obj, "status.latestVersion", err)}
+		}
+
+		annotations := rc.GetAnnotations()
+
+		switch {
+		case annotations[ocappsv1.DeploymentCancelledAnnotation] == "true":
+			return []*convert.Warning{RolloutCancelledWarning.At(obj, "status.latestVersion", rcname)}
+		case annotations[ocappsv1.DeploymentStatusAnnotation] == deploymentPhaseFailed:
+			return []*convert.Warning{RolloutFailedWarning.At(obj, "status.latestVersion", rcname)}
+		case annotations[ocappsv1.DeploymentStatusAnnotation] != deploymentPhaseComplete:
+			return []*convert.Warning{RolloutInProgressWarning.At(obj, "status.latestVersion", rcname+" "+annotations[ocappsv1.DeploymentStatusAnnotation])}
+		}
+	}
+
+	switch {
+	case dc.Status.ObservedGeneration < dc.Generation:
+		return []*convert.Warning{RolloutInProgressWarning.At(obj, "status.observedGeneration", fmt.Sprint(dc.Status.ObservedGeneration))}
+	case dc.Status.UpdatedReplicas != dc.Spec.Replicas && !dc.Spec.Paused:
+		return []*convert.Warning{RolloutInProgressWarning.At(obj, "status.updatedReplicas", fmt.Sprintf("%d/%d", dc.Status.UpdatedReplicas, dc.Spec.Replicas))}
+	case dc.Status.AvailableReplicas != dc.Spec.Replicas && !dc.Spec.Paused:
+		return []*convert.Warning{RolloutInProgressWarning.At(obj, "status.availableReplicas", fmt.Sprintf("%d/%d", dc.Status.AvailableReplicas, dc.Spec.Replicas))}
+	}
+
+	return nil
+}
+
+// WaitForStableDC polls the DeploymentConfig until CheckRollout no longer
+// reports an in progress rollout, or timeout passes. Failed and cancelled
+// rollouts do not resolve on their own and are returned immediately.
+func (cl *Cluster) WaitForStableDC(name string, namespace string, timeout time.Duration) (*ocappsv1.DeploymentConfig, error) {
+	var dc *ocappsv1.DeploymentConfig
+
+	err := wait.PollImmediate(rolloutPollInterval, timeout, func() (bool, error) {
+		var err error
+
+		dc, err = cl.LoadDC(name, namespace)
+		if err != nil {
+			return false, err
+		}
+
+		for _, w := range cl.CheckRollout(dc) {
+			if w.Code == RolloutInProgressWarning.Code {
+				writer.WriteErr(1, "waiting for deploymentconfig %s: %s %s", name, w.Path, w.Value)
+				return false, nil
+			}
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("deploymentconfig %s is not stable: %w", name, err)
+	}
+
+	return dc, nil
+}
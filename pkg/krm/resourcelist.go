@@ -0,0 +1,168 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package krm
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ResourceListKind = "ResourceList"
+	APIVersion       = "config.kubernetes.io/v1"
+
+	ErrorSeverity   Severity = "error"
+	WarningSeverity Severity = "warning"
+	InfoSeverity    Severity = "info"
+)
+
+// pathAnnotations and indexAnnotations locate an item in the files of the
+// pipeline, in the current and the legacy form.
+var (
+	pathAnnotations  = []string{"internal.config.kubernetes.io/path", "config.kubernetes.io/path"}
+	indexAnnotations = []string{"internal.config.kubernetes.io/index", "config.kubernetes.io/index"}
+)
+
+type Severity string
+
+// ResourceList is the input and output of a KRM function.
+type ResourceList struct {
+	APIVersion     string                       `json:"apiVersion"`
+	Kind           string                       `json:"kind"`
+	Items          []*unstructured.Unstructured `json:"items"`
+	FunctionConfig *unstructured.Unstructured   `json:"functionConfig,omitempty"`
+	Results        []*Result                    `json:"results,omitempty"`
+}
+
+// Result reports something the function found about an item.
+type Result struct {
+	Message     string       `json:"message"`
+	Severity    Severity     `json:"severity,omitempty"`
+	ResourceRef *ResourceRef `json:"resourceRef,omitempty"`
+	Field       *Field       `json:"field,omitempty"`
+	File        *File        `json:"file,omitempty"`
+}
+
+type ResourceRef struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+type Field struct {
+	Path string `json:"path"`
+}
+
+type File struct {
+	Path  string `json:"path"`
+	Index int    `json:"index,omitempty"`
+}
+
+// Read parses the ResourceList in r.
+func Read(r io.Reader) (*ResourceList, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read resource list: %w", err)
+	}
+
+	l := &ResourceList{}
+
+	if err := yaml.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("unable to parse resource list: %w", err)
+	}
+
+	if l.Kind != ResourceListKind {
+		return nil, fmt.Errorf("unexpected kind %q (want %s)", l.Kind, ResourceListKind)
+	}
+
+	return l, nil
+}
+
+// Marshal returns l as YAML.
+func (l *ResourceList) Marshal() ([]byte, error) {
+	return yaml.Marshal(l)
+}
+
+// Options returns the data of the ConfigMap given as the functionConfig.
+func (l *ResourceList) Options() (map[string]string, error) {
+	if l.FunctionConfig == nil {
+		return nil, nil
+	}
+
+	if kind := l.FunctionConfig.GetKind(); kind != "ConfigMap" {
+		return nil, fmt.Errorf("unsupported functionConfig kind %s (use ConfigMap)", kind)
+	}
+
+	data, _, err := unstructured.NestedStringMap(l.FunctionConfig.Object, "data")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read functionConfig: %w", err)
+	}
+
+	return data, nil
+}
+
+// NewResult returns a Result about the item u, located in the files of the
+// pipeline when u carries their annotations.
+func NewResult(u *unstructured.Unstructured, severity Severity, path, message string) *Result {
+	r := &Result{
+		Message:  message,
+		Severity: severity,
+		ResourceRef: &ResourceRef{
+			APIVersion: u.GetAPIVersion(),
+			Kind:       u.GetKind(),
+			Name:       u.GetName(),
+			Namespace:  u.GetNamespace(),
+		},
+	}
+
+	if path != "" {
+		r.Field = &Field{Path: path}
+	}
+
+	annotations := u.GetAnnotations()
+
+	for _, a := range pathAnnotations {
+		if p, ok := annotations[a]; ok {
+			r.File = &File{Path: p}
+			break
+		}
+	}
+
+	if r.File == nil {
+		return r
+	}
+
+	for _, a := range indexAnnotations {
+		if i, err := strconv.Atoi(annotations[a]); err == nil {
+			r.File.Index = i
+			break
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,299 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package kustomize
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	BaseDir     = "base"
+	OverlaysDir = "overlays"
+
+	kustomizationFile = "kustomization.yaml"
+)
+
+var fileNameRE = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// Layout is a kustomize base holding converted objects and overlays holding
+// the differences of variants of them, such as the conversions of the dev,
+// stage and prod files of the same DeploymentConfig.
+type Layout struct {
+	base     []*unstructured.Unstructured
+	overlays map[string][]*unstructured.Unstructured
+}
+
+// Kustomization is the subset of a kustomization.yaml written for a layout.
+type Kustomization struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Resources  []string `json:"resources,omitempty"`
+	Patches    []Patch  `json:"patches,omitempty"`
+}
+
+type Patch struct {
+	Path string `json:"path"`
+}
+
+func NewLayout(base []*unstructured.Unstructured) *Layout {
+	return &Layout{
+		base:     base,
+		overlays: map[string][]*unstructured.Unstructured{},
+	}
+}
+
+// AddOverlay adds the overlay name holding the differences of objs from the
+// base. Objects the base does not have are added as they are.
+func (l *Layout) AddOverlay(name string, objs []*unstructured.Unstructured) {
+	l.overlays[name] = objs
+}
+
+// Write writes the base and the overlays to dir, creating it if needed. The
+// namespace of the objects moves to the kustomizations.
+func (l *Layout) Write(dir string) error {
+	namespace := commonNamespace(l.base)
+
+	base := newKustomization(namespace)
+
+	for _, u := range l.base {
+		name := fileName(u, "")
+		base.Resources = append(base.Resources, name)
+
+		if err := writeYAML(filepath.Join(dir, BaseDir, name), clean(u).Object); err != nil {
+			return err
+		}
+	}
+
+	if err := writeYAML(filepath.Join(dir, BaseDir, kustomizationFile), base); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(l.overlays))
+	for name := range l.overlays {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := l.writeOverlay(filepath.Join(dir, OverlaysDir, name), namespace, l.overlays[name]); err != nil {
+			return fmt.Errorf("unable to write overlay %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Layout) writeOverlay(dir, baseNamespace string, objs []*unstructured.Unstructured) error {
+	k := newKustomization("")
+	k.Resources = []string{filepath.Join("..", "..", BaseDir)}
+
+	if namespace := commonNamespace(objs); namespace != baseNamespace {
+		k.Namespace = namespace
+	}
+
+	for _, u := range objs {
+		b := l.find(u)
+
+		if b == nil {
+			name := fileName(u, "")
+			k.Resources = append(k.Resources, name)
+
+			if err := writeYAML(filepath.Join(dir, name), clean(u).Object); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		patch, err := diff(clean(b), clean(u))
+		if err != nil {
+			return fmt.Errorf("unable to diff %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+
+		if patch == nil {
+			continue
+		}
+
+		name := fileName(u, "-patch")
+		k.Patches = append(k.Patches, Patch{Path: name})
+
+		if err := writeYAML(filepath.Join(dir, name), patch); err != nil {
+			return err
+		}
+	}
+
+	return writeYAML(filepath.Join(dir, kustomizationFile), k)
+}
+
+// find returns the object of the base with the kind and name of u.
+func (l *Layout) find(u *unstructured.Unstructured) *unstructured.Unstructured {
+	for _, b := range l.base {
+		if b.GroupVersionKind().GroupKind() == u.GroupVersionKind().GroupKind() && b.GetName() == u.GetName() {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// diff returns the patch turning base into u, strategic for the kinds of the
+// Kubernetes API and a JSON merge patch for others, or nil if they are equal.
+func diff(base, u *unstructured.Unstructured) (map[string]interface{}, error) {
+	original, err := json.Marshal(base.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	modified, err := json.Marshal(u.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+
+	if typed, err := scheme.Scheme.New(u.GroupVersionKind()); err == nil {
+		data, err = strategicpatch.CreateTwoWayMergePatch(original, modified, typed)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err = jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, original)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var patch map[string]interface{}
+
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+
+	dropOrderDirectives(patch)
+
+	if len(patch) == 0 {
+		return nil, nil
+	}
+
+	metadata, _ := patch["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	metadata["name"] = u.GetName()
+	patch["metadata"] = metadata
+	patch["apiVersion"] = u.GetAPIVersion()
+	patch["kind"] = u.GetKind()
+
+	return patch, nil
+}
+
+// dropOrderDirectives removes the $setElementOrder directives from patch. Lists
+// merged by key keep the order of the base without them.
+func dropOrderDirectives(patch map[string]interface{}) {
+	for k, v := range patch {
+		if strings.HasPrefix(k, "$setElementOrder/") {
+			delete(patch, k)
+			continue
+		}
+
+		switch t := v.(type) {
+		case map[string]interface{}:
+			dropOrderDirectives(t)
+		case []interface{}:
+			for _, item := range t {
+				if m, ok := item.(map[string]interface{}); ok {
+					dropOrderDirectives(m)
+				}
+			}
+		}
+	}
+}
+
+// clean returns a copy of u without its namespace and the fields the
+// conversion leaves empty.
+func clean(u *unstructured.Unstructured) *unstructured.Unstructured {
+	c := u.DeepCopy()
+	c.SetNamespace("")
+	unstructured.RemoveNestedField(c.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(c.Object, "spec", "template", "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(c.Object, "status")
+
+	return c
+}
+
+// commonNamespace returns the namespace of objs if they all have the same one.
+func commonNamespace(objs []*unstructured.Unstructured) string {
+	if len(objs) == 0 {
+		return ""
+	}
+
+	namespace := objs[0].GetNamespace()
+
+	for _, u := range objs[1:] {
+		if u.GetNamespace() != namespace {
+			return ""
+		}
+	}
+
+	return namespace
+}
+
+func newKustomization(namespace string) *Kustomization {
+	return &Kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Namespace:  namespace,
+	}
+}
+
+func fileName(u *unstructured.Unstructured, suffix string) string {
+	return strings.Trim(fileNameRE.ReplaceAllString(strings.ToLower(u.GetName()+"-"+u.GetKind()+suffix), "-"), "-") + ".yaml"
+}
+
+func writeYAML(path string, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %s: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit renders results as a JUnit XML report with one testcase per
+// DeploymentConfig. A testcase fails if any of its warnings block.
+func JUnit(results []*Result) ([]byte, error) {
+	suite := junitTestSuite{Name: toolName}
+
+	for _, r := range results {
+		tc := junitTestCase{
+			Name:      r.Object.Kind + "/" + r.Object.Name,
+			ClassName: r.Object.Namespace,
+		}
+
+		if r.File != "-" {
+			tc.File = r.File
+		}
+
+		var out strings.Builder
+
+		for _, w := range r.Warnings {
+			fmt.Fprintf(&out, "%s [%s/%s] %s: %s\n", w.Name, w.Code, w.Severity, w.Path, w.Description)
+		}
+
+		tc.SystemOut = out.String()
+
+		if blocking := r.blocking(); blocking != nil {
+			var codes []string
+
+			for _, w := range blocking {
+				codes = append(codes, w.Code)
+			}
+
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d blocking warnings", len(blocking)),
+				Type:    strings.Join(codes, ","),
+				Text:    tc.SystemOut,
+			}
+			suite.Failures++
+		}
+
+		suite.TestCases = append(suite.TestCases, tc)
+		suite.Tests++
+	}
+
+	o, err := xml.MarshalIndent(&junitTestSuites{
+		Name:     toolName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal junit: %w", err)
+	}
+
+	return append([]byte(xml.Header), o...), nil
+}
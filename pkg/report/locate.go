@@ -0,0 +1,150 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package report
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Locate returns the line and column of the field at path in the first
+// DeploymentConfig document of data. If path does not exist the position of
+// its closest existing parent is returned. Zero is returned if data can not
+// be parsed.
+func Locate(data []byte, path string) (int, int) {
+	doc := findDocument(data, "DeploymentConfig")
+	if doc == nil {
+		return 0, 0
+	}
+
+	node := doc
+
+	for _, key := range splitPath(path) {
+		next := child(node, key)
+		if next == nil {
+			break
+		}
+
+		node = next
+	}
+
+	return node.Line, node.Column
+}
+
+func findDocument(data []byte, kind string) *yaml.Node {
+	var first *yaml.Node
+
+	d := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		doc := &yaml.Node{}
+		if err := d.Decode(doc); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return first
+			}
+
+			break
+		}
+
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		root := doc.Content[0]
+		if first == nil {
+			first = root
+		}
+
+		if k := child(root, "kind"); k != nil && k.Value == kind {
+			return root
+		}
+	}
+
+	return first
+}
+
+func child(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+
+	return nil
+}
+
+// splitPath splits a warning path such as
+// "spec.triggers[1].imageChangeParams" or "metadata.annotations['a.b/c']"
+// into its keys.
+func splitPath(path string) []string {
+	var (
+		keys []string
+		key  strings.Builder
+	)
+
+	flush := func() {
+		if key.Len() != 0 {
+			keys = append(keys, key.String())
+			key.Reset()
+		}
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '.':
+			flush()
+		case '[':
+			flush()
+
+			end := strings.IndexByte(path[i:], ']')
+			if end == -1 {
+				key.WriteString(path[i+1:])
+
+				i = len(path)
+
+				continue
+			}
+
+			keys = append(keys, strings.Trim(path[i+1:i+end], `'"`))
+			i += end
+		default:
+			key.WriteByte(c)
+		}
+	}
+
+	flush()
+
+	return keys
+}
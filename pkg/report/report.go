@@ -0,0 +1,69 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package report
+
+import (
+	"os"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+)
+
+// Result holds the warnings raised for one DeploymentConfig. File is the
+// manifest it was loaded from, if any, and is used to locate warnings.
+type Result struct {
+	File     string
+	Object   convert.ObjectReference
+	Warnings []*convert.Warning
+
+	data []byte
+}
+
+func (r *Result) locate(path string) (int, int) {
+	if r.File == "" || r.File == "-" {
+		return 0, 0
+	}
+
+	if r.data == nil {
+		data, err := os.ReadFile(r.File)
+		if err != nil {
+			return 0, 0
+		}
+
+		r.data = data
+	}
+
+	return Locate(r.data, path)
+}
+
+// blocking returns the warnings of r that are not allowed.
+func (r *Result) blocking() []*convert.Warning {
+	var result []*convert.Warning
+
+	for _, w := range r.Warnings {
+		if !w.Allowed {
+			result = append(result, w)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,211 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "dc2deploy"
+	toolURI      = "https://github.com/csfreak/dc2deploy"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	FullDescription      sarifMessage       `json:"fullDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifSuppression struct {
+	Kind string `json:"kind"`
+}
+
+// SARIF renders results as a SARIF 2.1.0 log with one rule per warning code.
+// Allowed warnings are reported as externally suppressed.
+func SARIF(results []*Result) ([]byte, error) {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+
+	for _, r := range results {
+		for _, w := range r.Warnings {
+			if !rules[w.Code] {
+				rules[w.Code] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:                   w.Code,
+					Name:                 w.Name,
+					ShortDescription:     sarifMessage{Text: w.Name},
+					FullDescription:      sarifMessage{Text: w.Description},
+					DefaultConfiguration: sarifConfiguration{Level: sarifLevel(w.Severity)},
+				})
+			}
+
+			run.Results = append(run.Results, sarifResultFor(r, w))
+		}
+	}
+
+	o, err := json.MarshalIndent(&sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal sarif: %w", err)
+	}
+
+	return o, nil
+}
+
+func sarifResultFor(r *Result, w *convert.Warning) sarifResult {
+	text := w.Description
+	if w.Value != "" {
+		text = fmt.Sprintf("%s (%s: %s)", text, w.Path, w.Value)
+	}
+
+	loc := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{{
+			FullyQualifiedName: objectName(w.Object) + ":" + w.Path,
+			Kind:               "member",
+		}},
+	}
+
+	if r.File != "" && r.File != "-" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: r.File},
+		}
+
+		if line, col := r.locate(w.Path); line != 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: line, StartColumn: col}
+		}
+	}
+
+	result := sarifResult{
+		RuleID:    w.Code,
+		Level:     sarifLevel(w.Severity),
+		Message:   sarifMessage{Text: text},
+		Locations: []sarifLocation{loc},
+	}
+
+	if w.Allowed {
+		result.Suppressions = []sarifSuppression{{Kind: "external"}}
+	}
+
+	return result
+}
+
+func sarifLevel(s convert.Severity) string {
+	switch s {
+	case convert.InfoSeverity:
+		return "note"
+	case convert.BlockingSeverity:
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+func objectName(o convert.ObjectReference) string {
+	if o.Namespace == "" {
+		return o.Kind + "/" + o.Name
+	}
+
+	return o.Namespace + "/" + o.Kind + "/" + o.Name
+}
@@ -0,0 +1,304 @@
+/*
+Copyright © 2022 Jason Ross
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/csfreak/dc2deploy/pkg/convert"
+)
+
+type ScanStatus string
+
+const (
+	// ReadyStatus DeploymentConfigs convert without warnings.
+	ReadyStatus ScanStatus = "ready"
+	// ReviewStatus DeploymentConfigs convert, but lose features on the way.
+	ReviewStatus ScanStatus = "review"
+	// BlockedStatus DeploymentConfigs do not convert without changes or
+	// allowing their warnings.
+	BlockedStatus ScanStatus = "blocked"
+)
+
+// ScanEntry is the migration readiness of one DeploymentConfig. Blockers are
+// the warnings that block its conversion and Warnings the allowed ones that are
+// not informational. References counts the distinct objects its pod template
+// references.
+type ScanEntry struct {
+	Namespace  string             `json:"namespace"`
+	Name       string             `json:"name"`
+	Status     ScanStatus         `json:"status"`
+	Blockers   int                `json:"blockers"`
+	Warnings   int                `json:"warnings"`
+	Hooks      int                `json:"hooks"`
+	Triggers   int                `json:"triggers"`
+	References int                `json:"references"`
+	Findings   []*convert.Warning `json:"findings"`
+}
+
+// NamespaceSummary rolls up the ScanEntries of a namespace. Its Status is the
+// worst status of its DeploymentConfigs.
+type NamespaceSummary struct {
+	Namespace         string     `json:"namespace"`
+	Status            ScanStatus `json:"status"`
+	DeploymentConfigs int        `json:"deploymentConfigs"`
+	Ready             int        `json:"ready"`
+	Review            int        `json:"review"`
+	Blocked           int        `json:"blocked"`
+	Blockers          int        `json:"blockers"`
+	Warnings          int        `json:"warnings"`
+	Hooks             int        `json:"hooks"`
+	Triggers          int        `json:"triggers"`
+	References        int        `json:"references"`
+}
+
+// Scan is a migration readiness report.
+type Scan struct {
+	Generated         time.Time           `json:"generated"`
+	Namespaces        []*NamespaceSummary `json:"namespaces"`
+	DeploymentConfigs []*ScanEntry        `json:"deploymentConfigs"`
+}
+
+// NewScanEntry counts the findings w of a DeploymentConfig.
+func NewScanEntry(obj convert.ObjectReference, w []*convert.Warning) *ScanEntry {
+	e := &ScanEntry{
+		Namespace: obj.Namespace,
+		Name:      obj.Name,
+		Status:    ReadyStatus,
+		Findings:  w,
+	}
+
+	if e.Findings == nil {
+		e.Findings = []*convert.Warning{}
+	}
+
+	for _, f := range w {
+		switch {
+		case !f.Allowed:
+			e.Blockers++
+		case f.Severity != convert.InfoSeverity:
+			e.Warnings++
+		}
+	}
+
+	switch {
+	case e.Blockers > 0:
+		e.Status = BlockedStatus
+	case e.Warnings > 0:
+		e.Status = ReviewStatus
+	}
+
+	return e
+}
+
+// NewScan sorts entries by namespace and name and rolls them up per namespace.
+func NewScan(entries []*ScanEntry) *Scan {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Namespace != entries[j].Namespace {
+			return entries[i].Namespace < entries[j].Namespace
+		}
+
+		return entries[i].Name < entries[j].Name
+	})
+
+	s := &Scan{
+		Generated:         time.Now().UTC(),
+		Namespaces:        []*NamespaceSummary{},
+		DeploymentConfigs: entries,
+	}
+
+	var ns *NamespaceSummary
+
+	for _, e := range entries {
+		if ns == nil || ns.Namespace != e.Namespace {
+			ns = &NamespaceSummary{Namespace: e.Namespace, Status: ReadyStatus}
+			s.Namespaces = append(s.Namespaces, ns)
+		}
+
+		ns.DeploymentConfigs++
+		ns.Blockers += e.Blockers
+		ns.Warnings += e.Warnings
+		ns.Hooks += e.Hooks
+		ns.Triggers += e.Triggers
+		ns.References += e.References
+
+		switch e.Status {
+		case ReadyStatus:
+			ns.Ready++
+		case ReviewStatus:
+			ns.Review++
+
+			if ns.Status == ReadyStatus {
+				ns.Status = ReviewStatus
+			}
+		case BlockedStatus:
+			ns.Blocked++
+			ns.Status = BlockedStatus
+		}
+	}
+
+	return s
+}
+
+// ScanTable renders s as a table of DeploymentConfigs followed by the
+// namespace rollup.
+func ScanTable(s *Scan) ([]byte, error) {
+	var b bytes.Buffer
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tNAME\tSTATUS\tBLOCKERS\tWARNINGS\tHOOKS\tTRIGGERS\tREFERENCES")
+
+	for _, e := range s.DeploymentConfigs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+			e.Namespace, e.Name, e.Status, e.Blockers, e.Warnings, e.Hooks, e.Triggers, e.References)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "NAMESPACE\tSTATUS\tDEPLOYMENTCONFIGS\tREADY\tREVIEW\tBLOCKED\tBLOCKERS\tWARNINGS")
+
+	for _, ns := range s.Namespaces {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			ns.Namespace, ns.Status, ns.DeploymentConfigs, ns.Ready, ns.Review, ns.Blocked, ns.Blockers, ns.Warnings)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return nil, fmt.Errorf("unable to render scan: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// ScanCSV renders s as CSV with a row per DeploymentConfig followed by a row
+// per namespace, told apart by the kind column.
+func ScanCSV(s *Scan) ([]byte, error) {
+	var b bytes.Buffer
+
+	w := csv.NewWriter(&b)
+	rows := [][]string{{
+		"kind", "namespace", "name", "status", "deploymentConfigs", "ready", "review", "blocked",
+		"blockers", "warnings", "hooks", "triggers", "references",
+	}}
+
+	for _, e := range s.DeploymentConfigs {
+		rows = append(rows, []string{
+			"DeploymentConfig", e.Namespace, e.Name, string(e.Status), "", "", "", "",
+			strconv.Itoa(e.Blockers), strconv.Itoa(e.Warnings), strconv.Itoa(e.Hooks), strconv.Itoa(e.Triggers), strconv.Itoa(e.References),
+		})
+	}
+
+	for _, ns := range s.Namespaces {
+		rows = append(rows, []string{
+			"Namespace", ns.Namespace, "", string(ns.Status),
+			strconv.Itoa(ns.DeploymentConfigs), strconv.Itoa(ns.Ready), strconv.Itoa(ns.Review), strconv.Itoa(ns.Blocked),
+			strconv.Itoa(ns.Blockers), strconv.Itoa(ns.Warnings), strconv.Itoa(ns.Hooks), strconv.Itoa(ns.Triggers), strconv.Itoa(ns.References),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("unable to render scan: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// ScanJSON renders s as JSON, including the findings of each
+// DeploymentConfig.
+func ScanJSON(s *Scan) ([]byte, error) {
+	o, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal scan: %w", err)
+	}
+
+	return o, nil
+}
+
+// ScanHTML renders s as a self-contained HTML page.
+func ScanHTML(s *Scan) ([]byte, error) {
+	var b bytes.Buffer
+
+	if err := scanTemplate.Execute(&b, s); err != nil {
+		return nil, fmt.Errorf("unable to render scan: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+var scanTemplate = template.Must(template.New("scan").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>DeploymentConfig migration readiness</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #f0f0f0; }
+td.n { text-align: right; }
+.ready { color: #2e7d32; font-weight: bold; }
+.review { color: #ef6c00; font-weight: bold; }
+.blocked { color: #c62828; font-weight: bold; }
+details { margin: 0.5em 0; }
+</style>
+</head>
+<body>
+<h1>DeploymentConfig migration readiness</h1>
+<p>Generated {{ .Generated.Format "2006-01-02 15:04:05 MST" }} by dc2deploy.</p>
+<h2>Namespaces</h2>
+<table>
+<tr><th>Namespace</th><th>Status</th><th>DeploymentConfigs</th><th>Ready</th><th>Review</th><th>Blocked</th><th>Blockers</th><th>Warnings</th><th>Hooks</th><th>Triggers</th><th>References</th></tr>
+{{- range .Namespaces }}
+<tr><td>{{ .Namespace }}</td><td class="{{ .Status }}">{{ .Status }}</td><td class="n">{{ .DeploymentConfigs }}</td><td class="n">{{ .Ready }}</td><td class="n">{{ .Review }}</td><td class="n">{{ .Blocked }}</td><td class="n">{{ .Blockers }}</td><td class="n">{{ .Warnings }}</td><td class="n">{{ .Hooks }}</td><td class="n">{{ .Triggers }}</td><td class="n">{{ .References }}</td></tr>
+{{- end }}
+</table>
+<h2>DeploymentConfigs</h2>
+<table>
+<tr><th>Namespace</th><th>Name</th><th>Status</th><th>Blockers</th><th>Warnings</th><th>Hooks</th><th>Triggers</th><th>References</th></tr>
+{{- range .DeploymentConfigs }}
+<tr><td>{{ .Namespace }}</td><td>{{ .Name }}</td><td class="{{ .Status }}">{{ .Status }}</td><td class="n">{{ .Blockers }}</td><td class="n">{{ .Warnings }}</td><td class="n">{{ .Hooks }}</td><td class="n">{{ .Triggers }}</td><td class="n">{{ .References }}</td></tr>
+{{- end }}
+</table>
+<h2>Findings</h2>
+{{- range .DeploymentConfigs }}
+{{- if .Findings }}
+<details{{ if eq .Status "blocked" }} open{{ end }}>
+<summary>{{ .Namespace }}/{{ .Name }} <span class="{{ .Status }}">{{ .Status }}</span></summary>
+<table>
+<tr><th>Code</th><th>Severity</th><th>Blocks</th><th>Path</th><th>Value</th><th>Description</th></tr>
+{{- range .Findings }}
+<tr><td>{{ .Code }}</td><td>{{ .Severity }}</td><td>{{ if .Allowed }}no{{ else }}yes{{ end }}</td><td><code>{{ .Path }}</code></td><td>{{ .Value }}</td><td>{{ .Description }}</td></tr>
+{{- end }}
+</table>
+</details>
+{{- end }}
+{{- end }}
+</body>
+</html>
+`))
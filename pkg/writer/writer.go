@@ -54,9 +54,16 @@ func WriteErr(level uint8, fstring string, args ...interface{}) {
 }
 
 func WriteFile(path string, data []byte) error {
+	// data is written as it is, not as a format string, as it may hold a %.
 	if path == "-" {
-		WriteOut(0, string(data))
-		return nil
+		s := string(data)
+		if !strings.HasSuffix(s, "\n") {
+			s += "\n"
+		}
+
+		_, err := fmt.Fprint(os.Stdout, s)
+
+		return err
 	}
 
 	return os.WriteFile(path, data, 0644)